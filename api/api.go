@@ -0,0 +1,16 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+var (
+	//go:embed openapi.json
+	Spec []byte
+)
+
+func Handler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(Spec)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"github.com/alancesar/imgur-fetcher/internal/app"
+	"github.com/alancesar/imgur-fetcher/internal/cli"
+	"github.com/alancesar/imgur-fetcher/internal/downloader"
+	"github.com/alancesar/imgur-fetcher/internal/web"
+	"github.com/alancesar/imgur-fetcher/internal/worker"
+)
+
+func main() {
+	app.Main("imgur-fetcher",
+		app.Command{
+			Name:     "web",
+			Usage:    "serve the HTTP API",
+			Required: web.Required,
+			Setup:    web.Setup,
+		},
+		app.Command{
+			Name:     "worker",
+			Usage:    "consume URLs from the fetcher queue and publish their media",
+			Required: worker.Required,
+			Setup:    worker.Setup,
+		},
+		app.Command{
+			Name:     "downloader",
+			Usage:    "consume resolved media and store the files",
+			Required: downloader.Required,
+			Setup:    downloader.Setup,
+		},
+		app.Command{
+			Name:      "all",
+			Usage:     "run the API, a worker and a downloader in one process",
+			Required:  append(append(append([]string{}, web.Required...), worker.Required...), downloader.Required...),
+			InProcess: true,
+			Setup: func(ctx context.Context, a *app.App) error {
+				if err := web.Setup(ctx, a); err != nil {
+					return err
+				}
+
+				if err := worker.Setup(ctx, a); err != nil {
+					return err
+				}
+
+				return downloader.Setup(ctx, a)
+			},
+		},
+		app.Command{
+			Name:     "resolve",
+			Usage:    "print the media URLs behind each Imgur URL given as argument",
+			Required: cli.Required,
+			Exec:     cli.Resolve,
+		},
+		app.Command{
+			Name:     "download",
+			Usage:    "resolve Imgur URLs and download their media to a local directory",
+			Required: cli.Required,
+			Exec:     cli.Download,
+		},
+	)
+}
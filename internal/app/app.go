@@ -0,0 +1,560 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/internal/config"
+	"github.com/alancesar/imgur-fetcher/pkg/dedup"
+	"github.com/alancesar/imgur-fetcher/pkg/health"
+	"github.com/alancesar/imgur-fetcher/pkg/httpcache"
+	"github.com/alancesar/imgur-fetcher/pkg/idempotency"
+	"github.com/alancesar/imgur-fetcher/pkg/imgur"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"github.com/alancesar/imgur-fetcher/pkg/metrics"
+	"github.com/alancesar/imgur-fetcher/pkg/pubsub"
+	"github.com/alancesar/imgur-fetcher/pkg/reddit"
+	"github.com/alancesar/imgur-fetcher/pkg/resolver"
+	"github.com/alancesar/imgur-fetcher/pkg/storage"
+	"github.com/alancesar/imgur-fetcher/pkg/tracing"
+	"github.com/alancesar/imgur-fetcher/pkg/transport"
+	"github.com/alancesar/imgur-fetcher/pkg/urlpolicy"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+	ErrInProcessOnly  = errors.New("the memory broker is only available to commands that consume what they publish")
+)
+
+type (
+	Setup func(ctx context.Context, a *App) error
+
+	Command struct {
+		Name      string
+		Usage     string
+		Required  []string
+		InProcess bool
+		Setup     Setup
+		Exec      Setup
+	}
+
+	App struct {
+		Name       string
+		Config     config.Config
+		Args       []string
+		Logger     *slog.Logger
+		Metrics    *metrics.Metrics
+		Health     *health.Registry
+		Lifecycle  *Lifecycle
+		HTTPClient *http.Client
+
+		apiClient    *http.Client
+		imgurMonitor *imgur.Monitor
+		connection   *amqp.Connection
+		memory       *pubsub.Memory
+		storage      storage.Backend
+		cache        *httpcache.Cache
+		admin        bool
+	}
+)
+
+func Main(program string, commands ...Command) {
+	if len(os.Args) < 2 {
+		usage(program, commands)
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(program, commands)
+		return
+	}
+
+	var cmd Command
+	for _, c := range commands {
+		if c.Name == name {
+			cmd = c
+		}
+	}
+
+	if cmd.Name == "" {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %s\n", ErrUnknownCommand, name)
+		usage(program, commands)
+		os.Exit(2)
+	}
+
+	cfg, args, err := config.Load(program+" "+name, os.Args[2:], os.LookupEnv)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+
+		_, _ = fmt.Fprintln(os.Stderr, "failed to load config:", err)
+		os.Exit(2)
+	}
+
+	if err := Run(cmd, cfg, args); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func Run(cmd Command, cfg config.Config, args []string) error {
+	if cfg.PrintConfig {
+		return cfg.Print(os.Stdout)
+	}
+
+	if cfg.Broker == config.BrokerMemory && !cmd.InProcess {
+		return fmt.Errorf("%s: %w", cmd.Name, ErrInProcessOnly)
+	}
+
+	required := append([]string{}, cmd.Required...)
+	if cfg.Broker == config.BrokerRabbitMQ && cmd.Setup != nil {
+		required = append(required, "rabbitmq.url")
+	}
+
+	if err := cfg.Validate(required...); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logs := os.Stdout
+	if cmd.Exec != nil {
+		logs = os.Stderr
+	}
+
+	a, err := New(ctx, cmd.Name, cfg, logs)
+	if err != nil {
+		return err
+	}
+
+	a.Args = args
+	if cmd.Exec != nil {
+		err := cmd.Exec(ctx, a)
+		return errors.Join(err, a.Lifecycle.stop(a.Lifecycle.hooks))
+	}
+
+	if err := cmd.Setup(ctx, a); err != nil {
+		a.Logger.Error("failed to set up "+cmd.Name, logging.KeyError, err)
+		_ = a.Lifecycle.stop(a.Lifecycle.hooks)
+		return err
+	}
+
+	return a.Lifecycle.Run(ctx)
+}
+
+func New(ctx context.Context, name string, cfg config.Config, logs io.Writer) (*App, error) {
+	logger, err := logging.New(logs, cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	logger = logger.With("process", name)
+	slog.SetDefault(logger)
+
+	tracerProvider, err := tracing.Setup(ctx, "imgur-fetcher-"+name, cfg.Tracing.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tracing: %w", err)
+	}
+
+	a := &App{
+		Name:         name,
+		Config:       cfg,
+		Logger:       logger,
+		Metrics:      metrics.New(),
+		Health:       health.New(),
+		Lifecycle:    NewLifecycle(logger, cfg.HTTP.ShutdownTimeout),
+		imgurMonitor: imgur.NewMonitor(cfg.Imgur.MonitorWindow),
+	}
+
+	a.Lifecycle.Append(Hook{
+		Name:   "tracing",
+		OnStop: tracerProvider.Shutdown,
+	})
+
+	a.HTTPClient = &http.Client{
+		Transport: a.WrapTransport(http.DefaultTransport),
+	}
+
+	a.apiClient = &http.Client{
+		Transport: a.APITransport(http.DefaultTransport),
+	}
+
+	if cfg.Broker == config.BrokerMemory {
+		a.memory = pubsub.NewMemory(0)
+		a.memory.Bind(cfg.RabbitMQ.FetcherQueue, cfg.RabbitMQ.FetcherExchange, cfg.RabbitMQ.FetcherKey)
+		a.memory.Bind(cfg.RabbitMQ.DownloadsQueue, cfg.RabbitMQ.DownloadsExchange, cfg.RabbitMQ.DownloadsKey)
+		a.Health.Register("broker", health.NotClosed(a.memory))
+		a.Lifecycle.Append(Hook{
+			Name: "memory broker",
+			OnStop: func(_ context.Context) error {
+				return a.memory.Close()
+			},
+		})
+	}
+
+	return a, nil
+}
+
+func (a *App) WrapTransport(next http.RoundTripper) http.RoundTripper {
+	return transport.NewUserAgentRoundTripper(a.Config.UserAgent, next)
+}
+
+func (a *App) APITransport(next http.RoundTripper) http.RoundTripper {
+	return a.WrapTransport(transport.NewTracingRoundTripper(transport.NewMetricsRoundTripper(a.Metrics, next)))
+}
+
+func (a *App) PolicyClient() *http.Client {
+	policy := urlpolicy.Default()
+	policy.MaxRedirects = a.Config.HTTP.MaxRedirects
+	if len(a.Config.HTTP.AllowedHosts) > 0 {
+		policy.AllowedHosts = a.Config.HTTP.AllowedHosts
+	}
+
+	return policy.Client(a.WrapTransport)
+}
+
+func (a *App) Storage() (storage.Backend, error) {
+	if a.storage != nil {
+		return a.storage, nil
+	}
+
+	backend, err := a.newStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	a.storage = backend
+	return backend, nil
+}
+
+func (a *App) Index() (dedup.Index, error) {
+	if !a.Config.Storage.Dedup {
+		return nil, nil
+	}
+
+	backend, err := a.Storage()
+	if err != nil {
+		return nil, err
+	}
+
+	return dedup.NewStorageIndex(backend), nil
+}
+
+func (a *App) Idempotency() (idempotency.Store, error) {
+	cfg := a.Config.Idempotency
+	switch cfg.Backend {
+	case config.IdempotencyNone:
+		return nil, nil
+	case config.IdempotencySQLite:
+		store, err := idempotency.NewSQLite(cfg.Path, cfg.TTL)
+		if err != nil {
+			return nil, err
+		}
+
+		a.Lifecycle.Append(Hook{
+			Name: "idempotency store",
+			OnStop: func(_ context.Context) error {
+				return store.Close()
+			},
+		})
+
+		return store, nil
+	case config.IdempotencyRedis:
+		store, err := idempotency.NewRedis(cfg.RedisURL, cfg.TTL)
+		if err != nil {
+			return nil, err
+		}
+
+		a.Lifecycle.Append(Hook{
+			Name: "idempotency store",
+			OnStop: func(_ context.Context) error {
+				return store.Close()
+			},
+		})
+
+		return store, nil
+	default:
+		return idempotency.NewMemory(cfg.Size, cfg.TTL), nil
+	}
+}
+
+func (a *App) newStorage() (storage.Backend, error) {
+	cfg := a.Config.Storage
+	switch cfg.Backend {
+	case config.StorageS3:
+		return storage.NewS3(a.HTTPClient, storage.S3Options{
+			Endpoint:        cfg.S3.Endpoint,
+			Region:          cfg.S3.Region,
+			Bucket:          cfg.S3.Bucket,
+			AccessKeyID:     cfg.S3.AccessKeyID,
+			SecretAccessKey: cfg.S3.SecretAccessKey,
+			PathStyle:       cfg.S3.PathStyle,
+		})
+	case config.StorageWebDAV:
+		return storage.NewWebDAV(a.HTTPClient, cfg.WebDAV.URL, cfg.WebDAV.Username, cfg.WebDAV.Password)
+	case config.StorageMemory:
+		return storage.NewMemory(), nil
+	default:
+		return storage.NewLocal(cfg.Dir), nil
+	}
+}
+
+func (a *App) Cache() (*httpcache.Cache, error) {
+	if a.cache != nil {
+		return a.cache, nil
+	}
+
+	cfg := a.Config.Cache
+	var store httpcache.Store
+	switch cfg.Backend {
+	case config.CacheNone:
+		return nil, nil
+	case config.CacheDisk:
+		store = httpcache.NewStorageStore(storage.NewLocal(cfg.Dir))
+	case config.CacheShared:
+		backend, err := a.Storage()
+		if err != nil {
+			return nil, err
+		}
+
+		store = httpcache.NewStorageStore(backend)
+	default:
+		store = httpcache.NewMemory(cfg.Size)
+	}
+
+	a.cache = httpcache.New(store, httpcache.Options{
+		TTL:          cfg.TTL,
+		NotFoundTTL:  cfg.NotFoundTTL,
+		FetchTimeout: a.Config.Imgur.Timeout,
+	})
+
+	return a.cache, nil
+}
+
+func (a *App) ImgurClient() (*imgur.Client, error) {
+	if !a.Health.Has("imgur") {
+		a.Health.Register("imgur", a.imgurMonitor.Check)
+	}
+
+	var next http.RoundTripper = transport.NewAuthorizationRoundTripper(func(_ context.Context) (string, error) {
+		return "Client-ID " + a.Config.Imgur.ClientID, nil
+	}, transport.NewObserverRoundTripper(a.apiClient.Transport, a.imgurMonitor))
+
+	cache, err := a.Cache()
+	if err != nil {
+		return nil, err
+	}
+
+	if cache != nil {
+		next = cache.Wrap(next)
+	}
+
+	authClient := &http.Client{
+		Transport: next,
+		Timeout:   a.Config.Imgur.Timeout,
+	}
+
+	account := imgur.AccountOptions{
+		MaxPages: a.Config.Imgur.MaxPages,
+	}
+
+	if token := a.Config.Imgur.AccessToken; token != "" {
+		account.OAuthClient = &http.Client{
+			Transport: transport.NewAuthorizationRoundTripper(func(_ context.Context) (string, error) {
+				return "Bearer " + token, nil
+			}, transport.NewObserverRoundTripper(a.apiClient.Transport, a.imgurMonitor)),
+			Timeout: a.Config.Imgur.Timeout,
+		}
+	}
+
+	return imgur.NewClient(authClient, account, a.Logger), nil
+}
+
+func (a *App) Resolver() (*resolver.Registry, error) {
+	imgurClient, err := a.ImgurClient()
+	if err != nil {
+		return nil, err
+	}
+
+	policyClient := a.PolicyClient()
+	links := resolver.NewRegistry()
+	links.Register(imgurClient, imgur.Hosts...)
+
+	registry := resolver.NewRegistry()
+	registry.Register(imgurClient, imgur.Hosts...)
+	registry.Register(reddit.NewClient(a.redditClient(), links, a.Logger), reddit.Hosts...)
+	registry.Fallback(resolver.NewDirect(policyClient), resolver.NewOpenGraph(policyClient))
+	return registry, nil
+}
+
+func (a *App) redditClient() *http.Client {
+	policy := urlpolicy.Policy{
+		AllowedHosts: reddit.Hosts,
+		MaxRedirects: a.Config.HTTP.MaxRedirects,
+	}
+
+	return &http.Client{
+		Transport:     a.APITransport(policy.Transport()),
+		CheckRedirect: policy.CheckRedirect,
+		Timeout:       a.Config.Imgur.Timeout,
+	}
+}
+
+func (a *App) VariantPolicy() (resolver.Policy, error) {
+	return resolver.ParsePolicy(a.Config.Variant.Policy, a.Config.Variant.MaxSize)
+}
+
+func (a *App) Connection() (*amqp.Connection, error) {
+	if a.connection != nil {
+		return a.connection, nil
+	}
+
+	connection, err := amqp.Dial(a.Config.RabbitMQ.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start amqp connection: %w", err)
+	}
+
+	a.connection = connection
+	a.Health.Register("amqp_connection", health.NotClosed(connection))
+	a.Lifecycle.Append(Hook{
+		Name: "amqp connection",
+		OnStop: func(_ context.Context) error {
+			return connection.Close()
+		},
+	})
+
+	return connection, nil
+}
+
+func (a *App) Channel(name string) (*amqp.Channel, error) {
+	connection, err := a.Connection()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := connection.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start amqp channel %s: %w", name, err)
+	}
+
+	a.Health.Register("amqp_"+name, health.NotClosed(channel))
+	a.Lifecycle.Append(Hook{
+		Name: "amqp channel " + name,
+		OnStop: func(_ context.Context) error {
+			return channel.Close()
+		},
+	})
+
+	return channel, nil
+}
+
+func (a *App) Publisher(exchange, key string) (*metrics.InstrumentedPublisher, error) {
+	if a.memory != nil {
+		return metrics.NewInstrumentedPublisher(a.Metrics, exchange, key, a.memory.Publisher(exchange, key)), nil
+	}
+
+	connection, err := a.Connection()
+	if err != nil {
+		return nil, err
+	}
+
+	publisher, err := pubsub.NewRabbitMQPublisher(connection, exchange, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s.%s publisher: %w", exchange, key, err)
+	}
+
+	a.Health.Register("amqp_publisher_"+exchange+"."+key, health.NotClosed(publisher))
+	a.Lifecycle.Append(Hook{
+		Name: "publisher " + exchange + "." + key,
+		OnStop: func(_ context.Context) error {
+			return publisher.Close()
+		},
+	})
+
+	return metrics.NewInstrumentedPublisher(a.Metrics, exchange, key, publisher), nil
+}
+
+func (a *App) Consume(queue string) (<-chan amqp.Delivery, error) {
+	if a.memory != nil {
+		return a.memory.Consume(queue), nil
+	}
+
+	channel, err := a.Channel("subscriber")
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := channel.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s consumer: %w", queue, err)
+	}
+
+	return deliveries, nil
+}
+
+func (a *App) Serve(name, port string, handler http.Handler) {
+	server := &http.Server{
+		Handler: handler,
+		Addr:    ":" + port,
+	}
+
+	a.Lifecycle.Append(Hook{
+		Name: name,
+		OnStart: func(_ context.Context) error {
+			go func() {
+				a.Logger.Info("listening", "server", name, "addr", server.Addr)
+				if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					a.Lifecycle.fail(fmt.Errorf("%s: %w", name, err))
+				}
+			}()
+
+			return nil
+		},
+		OnStop: server.Shutdown,
+	})
+}
+
+func (a *App) ServeAdmin() {
+	if a.admin {
+		return
+	}
+
+	a.admin = true
+	a.Serve("admin server", a.Config.Admin.Port, a.AdminHandler())
+}
+
+func (a *App) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", a.Health.Liveness)
+	mux.HandleFunc("/readyz", a.Health.Readiness)
+	mux.Handle("/metrics", a.Metrics.Handler())
+	mux.HandleFunc("/cache", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			httpcache.StatsHandler(a.cache)(w, r)
+		case http.MethodDelete:
+			httpcache.PurgeHandler(a.cache, imgur.CacheKeys)(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	return mux
+}
+
+func usage(program string, commands []Command) {
+	_, _ = fmt.Fprintf(os.Stderr, "usage: %s <command> [flags] [args]\n\ncommands:\n", program)
+	for _, c := range commands {
+		_, _ = fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.Name, c.Usage)
+	}
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/internal/config"
+	"testing"
+)
+
+func TestRun_SetupFailure(t *testing.T) {
+	errSetup := errors.New("setup failed")
+	cfg := config.Default()
+	cfg.Broker = config.BrokerMemory
+	cfg.Imgur.ClientID = "some-client-id"
+
+	cmd := Command{
+		Name:      "some-command",
+		InProcess: true,
+		Setup: func(_ context.Context, a *App) error {
+			a.Lifecycle.Go("some task", func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			})
+			return errSetup
+		},
+	}
+
+	if err := Run(cmd, cfg, nil); !errors.Is(err, errSetup) {
+		t.Errorf("Run() error = %v, wantErr %v", err, errSetup)
+	}
+}
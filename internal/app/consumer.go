@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/pkg/health"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"github.com/alancesar/imgur-fetcher/pkg/metrics"
+	"github.com/alancesar/imgur-fetcher/pkg/pubsub"
+	"github.com/alancesar/imgur-fetcher/pkg/tracing"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var (
+	ErrPermanent        = errors.New("permanent failure")
+	ErrDeliveriesClosed = errors.New("deliveries channel closed")
+)
+
+type (
+	Handler func(ctx context.Context, body []byte) error
+)
+
+func (a *App) Subscribe(queue string, handle Handler) error {
+	deliveries, err := a.Consume(queue)
+	if err != nil {
+		return err
+	}
+
+	activity := &health.Activity{}
+	a.Health.Register("consumer_"+queue, activity.Check)
+
+	handlerCtx, abort := context.WithCancel(context.Background())
+	a.Lifecycle.Append(Hook{
+		Name: "handlers " + queue,
+		OnStop: func(_ context.Context) error {
+			abort()
+			return nil
+		},
+	})
+
+	a.Lifecycle.Go("consumer "+queue, func(ctx context.Context) error {
+		activity.Start()
+		defer activity.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case delivery, ok := <-deliveries:
+				if !ok {
+					return ErrDeliveriesClosed
+				}
+
+				a.handleDelivery(handlerCtx, queue, delivery, handle)
+			}
+		}
+	})
+
+	return nil
+}
+
+func (a *App) handleDelivery(ctx context.Context, queue string, delivery amqp.Delivery, handle Handler) {
+	a.Metrics.ObserveMessage(queue, metrics.OutcomeConsumed)
+
+	ctx = pubsub.WithMessageID(ctx, delivery.MessageId)
+	ctx = logging.With(ctx,
+		logging.KeyMessageID, delivery.MessageId,
+		logging.KeyDeliveryTag, delivery.DeliveryTag,
+	)
+
+	ctx, span := tracing.StartConsume(ctx, delivery)
+	defer span.End()
+
+	if err := handle(ctx, delivery.Body); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		if errors.Is(err, ErrPermanent) {
+			a.Logger.ErrorContext(ctx, "failed to handle message, discarding", logging.KeyError, err)
+			_ = delivery.Ack(false)
+			a.Metrics.ObserveMessage(queue, metrics.OutcomeDiscarded)
+			return
+		}
+
+		a.Logger.ErrorContext(ctx, "failed to handle message", logging.KeyError, err)
+		_ = delivery.Nack(false, true)
+		a.Metrics.ObserveMessage(queue, metrics.OutcomeNacked)
+		return
+	}
+
+	a.Logger.DebugContext(ctx, "message handled")
+	_ = delivery.Ack(false)
+	a.Metrics.ObserveMessage(queue, metrics.OutcomeAcked)
+}
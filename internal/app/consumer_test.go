@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"github.com/alancesar/imgur-fetcher/internal/config"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestApp_Subscribe_Drain(t *testing.T) {
+	cfg := config.Default()
+	cfg.Broker = config.BrokerMemory
+	cfg.HTTP.ShutdownTimeout = 5 * time.Second
+
+	a, err := New(context.Background(), "some-command", cfg, io.Discard)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	started := make(chan struct{})
+	var handlerErr error
+	finished, closed := false, false
+	queue := cfg.RabbitMQ.FetcherQueue
+	if err := a.Subscribe(queue, func(ctx context.Context, _ []byte) error {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		handlerErr = ctx.Err()
+		closed = a.memory.IsClosed()
+		finished = true
+		return nil
+	}); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	publisher, err := a.Publisher(cfg.RabbitMQ.FetcherExchange, cfg.RabbitMQ.FetcherKey)
+	if err != nil {
+		t.Fatalf("Publisher() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_ = publisher.Send(context.Background(), map[string]string{"url": "https://imgur.com/AbC123"})
+		<-started
+		cancel()
+	}()
+
+	if err := a.Lifecycle.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if !finished {
+		t.Error("Run() returned before the in-flight handler finished")
+	}
+
+	if closed {
+		t.Error("broker closed before the in-flight handler finished")
+	}
+
+	if handlerErr != nil {
+		t.Errorf("handler context error = %v, want nil", handlerErr)
+	}
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type (
+	Hook struct {
+		Name    string
+		OnStart func(ctx context.Context) error
+		OnStop  func(ctx context.Context) error
+	}
+
+	Lifecycle struct {
+		logger      *slog.Logger
+		stopTimeout time.Duration
+		mu          sync.Mutex
+		hooks       []Hook
+		failures    chan error
+		wg          sync.WaitGroup
+	}
+)
+
+func NewLifecycle(logger *slog.Logger, stopTimeout time.Duration) *Lifecycle {
+	return &Lifecycle{
+		logger:      logger,
+		stopTimeout: stopTimeout,
+		failures:    make(chan error, 1),
+	}
+}
+
+func (l *Lifecycle) Append(hook Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.hooks = append(l.hooks, hook)
+}
+
+func (l *Lifecycle) Go(name string, fn func(ctx context.Context) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	started := false
+	l.Append(Hook{
+		Name: name,
+		OnStart: func(_ context.Context) error {
+			started = true
+			l.wg.Add(1)
+			go func() {
+				defer l.wg.Done()
+				defer close(done)
+				if err := fn(ctx); err != nil && !errors.Is(err, context.Canceled) {
+					l.fail(fmt.Errorf("%s: %w", name, err))
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			if !started {
+				return nil
+			}
+
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+func (l *Lifecycle) Run(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := append([]Hook(nil), l.hooks...)
+	l.mu.Unlock()
+
+	started := 0
+	var runErr error
+	for _, hook := range hooks {
+		if hook.OnStart != nil {
+			l.logger.Debug("starting component", "component", hook.Name)
+			if err := hook.OnStart(ctx); err != nil {
+				runErr = fmt.Errorf("failed to start %s: %w", hook.Name, err)
+				break
+			}
+		}
+
+		started++
+	}
+
+	if runErr == nil {
+		l.logger.Info("all systems go!")
+		select {
+		case <-ctx.Done():
+		case runErr = <-l.failures:
+			l.logger.Error("component failed", "error", runErr)
+		}
+	}
+
+	l.logger.Info("shutting down...")
+	stopErr := l.stop(hooks[:started])
+	l.wg.Wait()
+	l.logger.Info("good bye")
+
+	return errors.Join(runErr, stopErr)
+}
+
+func (l *Lifecycle) stop(hooks []Hook) error {
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if hook.OnStop == nil {
+			continue
+		}
+
+		l.logger.Debug("stopping component", "component", hook.Name)
+		ctx, cancel := context.WithTimeout(context.Background(), l.stopTimeout)
+		if err := hook.OnStop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", hook.Name, err))
+		}
+		cancel()
+	}
+
+	return errors.Join(errs...)
+}
+
+func (l *Lifecycle) fail(err error) {
+	select {
+	case l.failures <- err:
+	default:
+	}
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLifecycle_Run(t *testing.T) {
+	var calls []string
+	record := func(call string, err error) func(context.Context) error {
+		return func(_ context.Context) error {
+			calls = append(calls, call)
+			return err
+		}
+	}
+
+	errBoom := errors.New("boom")
+	l := NewLifecycle(logging.Discard(), time.Second)
+	l.Append(Hook{Name: "first", OnStart: record("start first", nil), OnStop: record("stop first", nil)})
+	l.Append(Hook{Name: "second", OnStop: record("stop second", nil)})
+	l.Go("task", func(_ context.Context) error {
+		return errBoom
+	})
+	l.Append(Hook{Name: "third", OnStart: record("start third", nil), OnStop: record("stop third", nil)})
+
+	err := l.Run(context.Background())
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("Run() error = %v, wantErr %v", err, errBoom)
+	}
+
+	want := "start first,start third,stop third,stop second,stop first"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("Run() calls = %v, want %v", got, want)
+	}
+}
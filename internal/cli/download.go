@@ -0,0 +1,328 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/internal/app"
+	"github.com/alancesar/imgur-fetcher/pkg/hls"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"github.com/alancesar/imgur-fetcher/pkg/resolver"
+	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+var (
+	ErrIncomplete   = errors.New("some media could not be downloaded")
+	ErrInvalidPath  = errors.New("template produced an invalid path")
+	ErrInvalidInput = errors.New("invalid input")
+)
+
+type (
+	Target struct {
+		Parent string
+		ID     string
+		Index  int
+		Title  string
+		Ext    string
+	}
+
+	Summary struct {
+		Resolved   int
+		Downloaded int
+		Skipped    int
+		Failed     int
+	}
+
+	job struct {
+		url  string
+		path string
+	}
+)
+
+func Download(ctx context.Context, a *app.App) error {
+	cfg := a.Config.Download
+	tmpl, err := template.New("path").Option("missingkey=error").Parse(cfg.Template)
+	if err != nil {
+		return fmt.Errorf("failed to parse download template: %w", err)
+	}
+
+	urls, err := readURLs(a.Args, cfg.Input, os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	var jobs []job
+	resolveErr := resolveAll(ctx, a, urls, func(rawURL string, mediaList []resolver.Media) error {
+		for i, m := range mediaList {
+			mediaURL := m.URL
+			if cfg.DryRun {
+				if _, err := fmt.Fprintln(os.Stdout, mediaURL); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			target, err := NewTarget(rawURL, mediaURL, m, i+1).Path(tmpl)
+			if err != nil {
+				return err
+			}
+
+			jobs = append(jobs, job{
+				url:  mediaURL,
+				path: filepath.Join(cfg.Dir, target),
+			})
+		}
+
+		return nil
+	})
+	if resolveErr != nil && !errors.Is(resolveErr, ErrUnresolved) {
+		return resolveErr
+	}
+
+	if cfg.DryRun {
+		return resolveErr
+	}
+
+	summary := download(ctx, a, a.PolicyClient(), jobs, cfg.Parallel)
+	_, _ = fmt.Fprintf(os.Stdout, "resolved %d, downloaded %d, skipped %d, failed %d\n",
+		summary.Resolved, summary.Downloaded, summary.Skipped, summary.Failed)
+
+	if summary.Failed > 0 {
+		return errors.Join(resolveErr, ErrIncomplete)
+	}
+
+	return resolveErr
+}
+
+func NewTarget(rawURL, mediaURL string, m resolver.Media, index int) Target {
+	parent := ""
+	if parsed, err := url.Parse(rawURL); err == nil {
+		parent = baseName(parsed.Path)
+		if parent == "" {
+			parent = parsed.Hostname()
+		}
+	}
+
+	id, ext := m.ID, ""
+	if parsed, err := url.Parse(mediaURL); err == nil {
+		ext = path.Ext(parsed.Path)
+		if id == "" {
+			id = baseName(parsed.Path)
+		}
+	}
+
+	if id == "" {
+		sum := sha256.Sum256([]byte(mediaURL))
+		id = hex.EncodeToString(sum[:8])
+	}
+
+	return Target{
+		Parent: parent,
+		ID:     id,
+		Index:  index,
+		Title:  strings.NewReplacer("/", "_", "\\", "_").Replace(m.Title),
+		Ext:    ext,
+	}
+}
+
+func (t Target) Path(tmpl *template.Template) (string, error) {
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, t); err != nil {
+		return "", fmt.Errorf("failed to render download template: %w", err)
+	}
+
+	target := filepath.Clean(filepath.FromSlash(builder.String()))
+	if !filepath.IsLocal(target) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPath, builder.String())
+	}
+
+	return target, nil
+}
+
+func readURLs(args []string, input string, stdin io.Reader) ([]string, error) {
+	urls := append([]string{}, args...)
+	if input == "" && len(args) > 0 {
+		return urls, nil
+	}
+
+	reader := stdin
+	if input != "" && input != "-" {
+		file, err := os.Open(input)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+
+		defer func() {
+			_ = file.Close()
+		}()
+
+		reader = file
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		urls = append(urls, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	return urls, nil
+}
+
+func download(ctx context.Context, a *app.App, client *http.Client, jobs []job, parallel int) Summary {
+	cfg := a.Config.Downloader
+	streams := hls.New(client, hls.Options{
+		Parallel:     cfg.HLSParallel,
+		Retries:      cfg.HLSRetries,
+		Select:       cfg.HLSSelect,
+		MaxBandwidth: cfg.HLSMaxBandwidth,
+		MaxHeight:    cfg.HLSMaxHeight,
+	})
+
+	summary := Summary{Resolved: len(jobs)}
+	queue := make(chan job)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				skipped, err := fetch(ctx, client, streams, j)
+				mu.Lock()
+				switch {
+				case err != nil:
+					a.Logger.Error("failed to download media", logging.KeyURL, j.url, "path", j.path, logging.KeyError, err)
+					summary.Failed++
+				case skipped:
+					a.Logger.Debug("file already exists, skipping", logging.KeyURL, j.url, "path", j.path)
+					summary.Skipped++
+				default:
+					a.Logger.Info("media downloaded", logging.KeyURL, j.url, "path", j.path)
+					summary.Downloaded++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, j := range jobs {
+		queue <- j
+	}
+
+	close(queue)
+	wg.Wait()
+	return summary
+}
+
+func fetch(ctx context.Context, client *http.Client, streams *hls.Fetcher, j job) (bool, error) {
+	if hls.IsPlaylist(j.url) {
+		return fetchStream(ctx, streams, j)
+	}
+
+	if _, err := os.Stat(j.path); err == nil {
+		return true, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("%w: %d (%s): %s", status.ErrBadStatus, res.StatusCode, res.Status, j.url)
+	}
+
+	return false, save(j.path, func(w io.Writer) error {
+		_, err := io.Copy(w, res.Body)
+		return err
+	})
+}
+
+func fetchStream(ctx context.Context, streams *hls.Fetcher, j job) (bool, error) {
+	stream, err := streams.Open(ctx, j.url)
+	if err != nil {
+		return false, err
+	}
+
+	target := strings.TrimSuffix(j.path, filepath.Ext(j.path)) + stream.Ext()
+	if _, err := os.Stat(target); err == nil {
+		return true, nil
+	}
+
+	return false, save(target, func(w io.Writer) error {
+		_, err := streams.Write(ctx, stream, w)
+		return err
+	})
+}
+
+func baseName(p string) string {
+	name := path.Base(p)
+	name = strings.TrimSuffix(name, path.Ext(name))
+	if name == "" || name == "." || name == "/" || strings.HasPrefix(name, ".") {
+		return ""
+	}
+
+	return name
+}
+
+func save(target string, write func(w io.Writer) error) error {
+	dir := filepath.Dir(target)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(dir, "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+
+	if err := write(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Chmod(0o644); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), target)
+}
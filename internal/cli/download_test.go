@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/internal/app"
+	"github.com/alancesar/imgur-fetcher/internal/config"
+	"github.com/alancesar/imgur-fetcher/pkg/resolver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestTarget_Path(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		target   Target
+		want     string
+		wantErr  error
+	}{
+		{
+			name:     "Should render the default layout",
+			template: "{{.Parent}}/{{.ID}}{{.Ext}}",
+			target:   NewTarget("https://imgur.com/a/some-album-id", "https://i.imgur.com/some-id.mp4", resolver.Media{ID: "some-id"}, 1),
+			want:     filepath.Join("some-album-id", "some-id.mp4"),
+		},
+		{
+			name:     "Should keep titles in a single path segment",
+			template: "{{.Index}} - {{.Title}}{{.Ext}}",
+			target:   NewTarget("https://imgur.com/some-id", "https://i.imgur.com/some-id.jpg", resolver.Media{ID: "some-id", Title: "a/b"}, 2),
+			want:     "2 - a_b.jpg",
+		},
+		{
+			name:     "Should name direct links after the file",
+			template: "{{.Parent}}/{{.ID}}{{.Ext}}",
+			target:   NewTarget("https://example.com/pics/pic.jpg", "https://example.com/pics/pic.jpg", resolver.Media{}, 1),
+			want:     filepath.Join("pic", "pic.jpg"),
+		},
+		{
+			name:     "Should name media without a file name after a hash of the URL",
+			template: "{{.Parent}}/{{.ID}}{{.Ext}}",
+			target:   NewTarget("https://example.com/", "https://example.com/", resolver.Media{}, 1),
+			want:     filepath.Join("example.com", "0f115db062b7c0dd"),
+		},
+		{
+			name:     "Should reject paths outside the directory",
+			template: "../{{.ID}}{{.Ext}}",
+			target:   NewTarget("https://imgur.com/some-id", "https://i.imgur.com/some-id.jpg", resolver.Media{ID: "some-id"}, 1),
+			wantErr:  ErrInvalidPath,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.target.Path(template.Must(template.New("path").Parse(tt.template)))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Path() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Path() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadURLs(t *testing.T) {
+	stdin := strings.NewReader("https://imgur.com/first\n\n# comment\n  https://imgur.com/second  \n")
+
+	got, err := readURLs(nil, "-", stdin)
+	if err != nil {
+		t.Fatalf("readURLs() error = %v", err)
+	}
+
+	want := []string{"https://imgur.com/first", "https://imgur.com/second"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readURLs() got = %v, want %v", got, want)
+	}
+
+	got, err = readURLs([]string{"https://imgur.com/arg"}, "", stdin)
+	if err != nil || !reflect.DeepEqual(got, []string{"https://imgur.com/arg"}) {
+		t.Errorf("readURLs() got = %v, %v, want only the arguments", got, err)
+	}
+}
+
+func TestDownload_DirectLink(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte("some-image"))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	a, err := app.New(ctx, "download", config.Default(), io.Discard)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	rawURL := server.URL + "/pics/pic.jpg"
+	mediaList, err := resolver.NewDirect(server.Client()).Resolve(ctx, rawURL, resolver.Options{})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	tmpl := template.Must(template.New("path").Parse(config.Default().Download.Template))
+	var jobs []job
+	for i, m := range mediaList {
+		target, err := NewTarget(rawURL, m.URL, m, i+1).Path(tmpl)
+		if err != nil {
+			t.Fatalf("Path() error = %v", err)
+		}
+
+		jobs = append(jobs, job{url: m.URL, path: filepath.Join(dir, target)})
+	}
+
+	if summary := download(ctx, a, server.Client(), jobs, 1); summary.Downloaded != 1 {
+		t.Fatalf("download() summary = %+v, want one download", summary)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "pic", "pic.jpg"))
+	if err != nil || string(content) != "some-image" {
+		t.Errorf("ReadFile() = %q, %v, want the downloaded file", content, err)
+	}
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/internal/app"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"github.com/alancesar/imgur-fetcher/pkg/resolver"
+	"github.com/alancesar/imgur-fetcher/pkg/validation"
+	"os"
+)
+
+var (
+	ErrNoURLs     = errors.New("no URLs given")
+	ErrUnresolved = errors.New("some URLs could not be resolved")
+
+	Required = []string{"imgur.client_id"}
+)
+
+type (
+	resolved func(rawURL string, mediaList []resolver.Media) error
+)
+
+func Resolve(ctx context.Context, a *app.App) error {
+	return resolveAll(ctx, a, a.Args, func(_ string, mediaList []resolver.Media) error {
+		for _, m := range mediaList {
+			if _, err := fmt.Fprintln(os.Stdout, m.URL); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func resolveAll(ctx context.Context, a *app.App, urls []string, fn resolved) error {
+	if len(urls) == 0 {
+		return ErrNoURLs
+	}
+
+	registry, err := a.Resolver()
+	if err != nil {
+		return err
+	}
+
+	policy, err := a.VariantPolicy()
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, arg := range urls {
+		rawURL, err := validation.NormalizeURL(arg)
+		if err != nil {
+			a.Logger.Error("invalid URL", logging.KeyURL, arg, logging.KeyError, err)
+			failed = true
+			continue
+		}
+
+		mediaList, err := registry.Resolve(logging.With(ctx, logging.KeyURL, rawURL), rawURL, resolver.Options{})
+		if err != nil {
+			a.Logger.Error("failed to resolve URL", logging.KeyURL, rawURL, logging.KeyError, err)
+			failed = true
+			continue
+		}
+
+		if err := fn(rawURL, policy.Apply(mediaList)); err != nil {
+			return err
+		}
+	}
+
+	if failed {
+		return ErrUnresolved
+	}
+
+	return nil
+}
@@ -0,0 +1,531 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	BrokerRabbitMQ = "rabbitmq"
+	BrokerMemory   = "memory"
+
+	StorageLocal  = "local"
+	StorageS3     = "s3"
+	StorageWebDAV = "webdav"
+	StorageMemory = "memory"
+
+	IdempotencyNone   = "none"
+	IdempotencyMemory = "memory"
+	IdempotencySQLite = "sqlite"
+	IdempotencyRedis  = "redis"
+
+	CacheNone   = "none"
+	CacheMemory = "memory"
+	CacheDisk   = "disk"
+	CacheShared = "shared"
+
+	HLSSelectBandwidth  = "bandwidth"
+	HLSSelectResolution = "resolution"
+
+	VariantMP4      = "mp4"
+	VariantSmallest = "smallest"
+	VariantOriginal = "original"
+	VariantAll      = "all"
+
+	redacted = "[REDACTED]"
+)
+
+var (
+	ErrInvalid = errors.New("invalid configuration")
+)
+
+type (
+	Lookup func(key string) (string, bool)
+
+	Config struct {
+		ConfigFile  string `yaml:"-" env:"CONFIG_FILE" flag:"config" usage:"path to a YAML configuration file"`
+		PrintConfig bool   `yaml:"-" flag:"print-config" usage:"print the effective configuration and exit"`
+		LogLevel    string `yaml:"log_level" env:"LOG_LEVEL" flag:"log-level" usage:"log level (debug, info, warn, error)"`
+		UserAgent   string `yaml:"user_agent" env:"USER_AGENT" flag:"user-agent" usage:"user agent sent on outgoing requests"`
+		Broker      string `yaml:"broker" env:"BROKER" flag:"broker" usage:"message broker (rabbitmq, or memory for the all command)"`
+
+		Imgur       Imgur       `yaml:"imgur"`
+		RabbitMQ    RabbitMQ    `yaml:"rabbitmq"`
+		HTTP        HTTP        `yaml:"http"`
+		Admin       Admin       `yaml:"admin"`
+		Tracing     Tracing     `yaml:"tracing"`
+		Download    Download    `yaml:"download"`
+		Downloader  Downloader  `yaml:"downloader"`
+		Storage     Storage     `yaml:"storage"`
+		Idempotency Idempotency `yaml:"idempotency"`
+		Cache       Cache       `yaml:"cache"`
+		Variant     Variant     `yaml:"variant"`
+	}
+
+	Imgur struct {
+		ClientID      string        `yaml:"client_id" env:"IMGUR_CLIENT_ID" flag:"imgur-client-id" secret:"true" usage:"Imgur API client ID"`
+		Timeout       time.Duration `yaml:"timeout" env:"IMGUR_TIMEOUT" flag:"imgur-timeout" usage:"timeout for Imgur and reddit API calls"`
+		MonitorWindow time.Duration `yaml:"monitor_window" env:"IMGUR_MONITOR_WINDOW" flag:"imgur-monitor-window" usage:"how long a failed Imgur call affects readiness"`
+		AccessToken   string        `yaml:"access_token" env:"IMGUR_ACCESS_TOKEN" flag:"imgur-access-token" secret:"true" usage:"Imgur OAuth access token, needed to crawl account images"`
+		MaxPages      int           `yaml:"max_pages" env:"IMGUR_MAX_PAGES" flag:"imgur-max-pages" usage:"pages listed per section when crawling an Imgur user, tag or subreddit, and the most an API request may ask for"`
+	}
+
+	RabbitMQ struct {
+		URL               string `yaml:"url" env:"RABBITMQ_URL" flag:"rabbitmq-url" secret:"true" usage:"AMQP connection URL"`
+		FetcherExchange   string `yaml:"fetcher_exchange" env:"FETCHER_EXCHANGE" flag:"fetcher-exchange" usage:"exchange for URLs to fetch"`
+		FetcherKey        string `yaml:"fetcher_key" env:"FETCHER_KEY" flag:"fetcher-key" usage:"routing key for URLs to fetch"`
+		FetcherQueue      string `yaml:"fetcher_queue" env:"FETCHER_QUEUE" flag:"fetcher-queue" usage:"queue consumed by the worker"`
+		DownloadsExchange string `yaml:"downloads_exchange" env:"DOWNLOADS_EXCHANGE" flag:"downloads-exchange" usage:"exchange for resolved media"`
+		DownloadsKey      string `yaml:"downloads_key" env:"DOWNLOADS_KEY" flag:"downloads-key" usage:"routing key for resolved media"`
+		DownloadsQueue    string `yaml:"downloads_queue" env:"DOWNLOADS_QUEUE" flag:"downloads-queue" usage:"queue consumed by the downloader"`
+		CompletedExchange string `yaml:"completed_exchange" env:"COMPLETED_EXCHANGE" flag:"completed-exchange" usage:"exchange for completed downloads"`
+		CompletedKey      string `yaml:"completed_key" env:"COMPLETED_KEY" flag:"completed-key" usage:"routing key for completed downloads"`
+	}
+
+	HTTP struct {
+		Port            string        `yaml:"port" env:"PORT" flag:"port" usage:"port the API listens on"`
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"graceful shutdown timeout"`
+		APIKeysFile     string        `yaml:"api_keys_file" env:"API_KEYS_FILE" flag:"api-keys-file" usage:"path to the API keys file"`
+		APIKeysReload   time.Duration `yaml:"api_keys_reload" env:"API_KEYS_RELOAD" flag:"api-keys-reload" usage:"how often the API keys file is checked for changes"`
+		AllowedHosts    []string      `yaml:"allowed_hosts" env:"ALLOWED_HOSTS" flag:"allowed-hosts" usage:"comma separated hosts accepted by /publish, the downloader and the generic direct and og resolvers, which only reach these hosts; * allows any public host"`
+		MaxRedirects    int           `yaml:"max_redirects" env:"MAX_REDIRECTS" flag:"max-redirects" usage:"redirects followed when probing published URLs"`
+	}
+
+	Admin struct {
+		Port string `yaml:"port" env:"ADMIN_PORT" flag:"admin-port" usage:"port of the worker admin listener"`
+	}
+
+	Tracing struct {
+		Endpoint string `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" flag:"otlp-endpoint" usage:"OTLP/HTTP endpoint for traces"`
+	}
+
+	Download struct {
+		Dir      string `yaml:"dir" env:"DOWNLOAD_DIR" flag:"dir" usage:"directory the download command writes to"`
+		Template string `yaml:"template" env:"DOWNLOAD_TEMPLATE" flag:"template" usage:"path template under dir, with {{.Parent}}, {{.ID}}, {{.Index}}, {{.Title}} and {{.Ext}}"`
+		Parallel int    `yaml:"parallel" env:"DOWNLOAD_PARALLEL" flag:"parallel" usage:"number of downloads run at the same time"`
+		Input    string `yaml:"-" flag:"input" usage:"file with one URL per line, or - for stdin"`
+		DryRun   bool   `yaml:"-" flag:"dry-run" usage:"only print the resolved URLs"`
+	}
+
+	Downloader struct {
+		MaxSize      int64    `yaml:"max_size" env:"DOWNLOADER_MAX_SIZE" flag:"max-size" usage:"largest file the downloader accepts, in bytes"`
+		AllowedTypes []string `yaml:"allowed_types" env:"DOWNLOADER_ALLOWED_TYPES" flag:"allowed-types" usage:"comma separated content types or type prefixes the downloader accepts"`
+		PartsDir     string   `yaml:"parts_dir" env:"DOWNLOADER_PARTS_DIR" flag:"parts-dir" usage:"directory for partial downloads kept between attempts"`
+
+		HLSParallel     int    `yaml:"hls_parallel" env:"DOWNLOADER_HLS_PARALLEL" flag:"hls-parallel" usage:"HLS segments downloaded at the same time"`
+		HLSRetries      int    `yaml:"hls_retries" env:"DOWNLOADER_HLS_RETRIES" flag:"hls-retries" usage:"times a failed HLS segment is retried"`
+		HLSSelect       string `yaml:"hls_select" env:"DOWNLOADER_HLS_SELECT" flag:"hls-select" usage:"how an HLS variant is chosen (bandwidth or resolution)"`
+		HLSMaxBandwidth int64  `yaml:"hls_max_bandwidth" env:"DOWNLOADER_HLS_MAX_BANDWIDTH" flag:"hls-max-bandwidth" usage:"ignore HLS variants above this bandwidth in bits per second, 0 for no cap"`
+		HLSMaxHeight    int    `yaml:"hls_max_height" env:"DOWNLOADER_HLS_MAX_HEIGHT" flag:"hls-max-height" usage:"ignore HLS variants taller than this many pixels, 0 for no cap"`
+	}
+
+	Storage struct {
+		Backend string `yaml:"backend" env:"STORAGE_BACKEND" flag:"storage-backend" usage:"where downloaded files are stored (local, s3, webdav or memory)"`
+		Dir     string `yaml:"dir" env:"STORAGE_DIR" flag:"storage-dir" usage:"directory of the local storage backend"`
+		Layout  string `yaml:"layout" env:"STORAGE_LAYOUT" flag:"storage-layout" usage:"object key template, with {{.Parent}}, {{.Name}}, {{.ID}}, {{.Ext}} and {{.Host}}"`
+		Dedup   bool   `yaml:"dedup" env:"STORAGE_DEDUP" flag:"storage-dedup" usage:"store each file once in a content-addressed blob store and link every path to it"`
+		S3      S3     `yaml:"s3"`
+		WebDAV  WebDAV `yaml:"webdav"`
+	}
+
+	S3 struct {
+		Endpoint        string `yaml:"endpoint" env:"S3_ENDPOINT" flag:"s3-endpoint" usage:"S3-compatible endpoint URL"`
+		Region          string `yaml:"region" env:"S3_REGION" flag:"s3-region" usage:"S3 region"`
+		Bucket          string `yaml:"bucket" env:"S3_BUCKET" flag:"s3-bucket" usage:"S3 bucket"`
+		AccessKeyID     string `yaml:"access_key_id" env:"S3_ACCESS_KEY_ID" flag:"s3-access-key-id" usage:"S3 access key ID"`
+		SecretAccessKey string `yaml:"secret_access_key" env:"S3_SECRET_ACCESS_KEY" flag:"s3-secret-access-key" secret:"true" usage:"S3 secret access key"`
+		PathStyle       bool   `yaml:"path_style" env:"S3_PATH_STYLE" flag:"s3-path-style" usage:"address buckets by path instead of by host, as MinIO does"`
+	}
+
+	WebDAV struct {
+		URL      string `yaml:"url" env:"WEBDAV_URL" flag:"webdav-url" usage:"WebDAV collection URL"`
+		Username string `yaml:"username" env:"WEBDAV_USERNAME" flag:"webdav-username" usage:"WebDAV user name"`
+		Password string `yaml:"password" env:"WEBDAV_PASSWORD" flag:"webdav-password" secret:"true" usage:"WebDAV password"`
+	}
+
+	Idempotency struct {
+		Backend  string        `yaml:"backend" env:"IDEMPOTENCY_BACKEND" flag:"idempotency-backend" usage:"where the worker remembers forwarded messages (memory, sqlite, redis or none)"`
+		TTL      time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" flag:"idempotency-ttl" usage:"how long a forwarded message is remembered"`
+		Size     int           `yaml:"size" env:"IDEMPOTENCY_SIZE" flag:"idempotency-size" usage:"keys kept by the memory backend"`
+		Path     string        `yaml:"path" env:"IDEMPOTENCY_PATH" flag:"idempotency-path" usage:"database file of the sqlite backend"`
+		RedisURL string        `yaml:"redis_url" env:"IDEMPOTENCY_REDIS_URL" flag:"idempotency-redis-url" secret:"true" usage:"redis:// or rediss:// (TLS) URL of the redis backend"`
+	}
+
+	Cache struct {
+		Backend     string        `yaml:"backend" env:"CACHE_BACKEND" flag:"cache-backend" usage:"where Imgur API responses are cached (memory, disk, shared storage or none)"`
+		TTL         time.Duration `yaml:"ttl" env:"CACHE_TTL" flag:"cache-ttl" usage:"how long a resolved Imgur response is served from the cache"`
+		NotFoundTTL time.Duration `yaml:"not_found_ttl" env:"CACHE_NOT_FOUND_TTL" flag:"cache-not-found-ttl" usage:"how long an Imgur 404 is served from the cache"`
+		Size        int           `yaml:"size" env:"CACHE_SIZE" flag:"cache-size" usage:"responses kept by the memory backend"`
+		Dir         string        `yaml:"dir" env:"CACHE_DIR" flag:"cache-dir" usage:"directory of the disk backend"`
+	}
+
+	Variant struct {
+		Policy  string `yaml:"policy" env:"VARIANT_POLICY" flag:"variant" usage:"which variant of each media is kept (mp4, smallest, original or all)"`
+		MaxSize int64  `yaml:"max_size" env:"VARIANT_MAX_SIZE" flag:"variant-max-size" usage:"drop variants larger than this many bytes, 0 for no cap"`
+	}
+
+	field struct {
+		path  string
+		env   string
+		flag  string
+		value reflect.Value
+		tag   reflect.StructTag
+	}
+)
+
+func Default() Config {
+	return Config{
+		LogLevel:  "info",
+		UserAgent: "imgur-fetcher",
+		Broker:    BrokerRabbitMQ,
+		Imgur: Imgur{
+			Timeout:       30 * time.Second,
+			MonitorWindow: time.Minute,
+			MaxPages:      50,
+		},
+		RabbitMQ: RabbitMQ{
+			FetcherExchange:   "fetcher",
+			FetcherKey:        "imgur",
+			FetcherQueue:      "fetcher.imgur",
+			DownloadsExchange: "media",
+			DownloadsKey:      "downloads",
+			DownloadsQueue:    "media.downloads",
+			CompletedExchange: "media",
+			CompletedKey:      "completed",
+		},
+		HTTP: HTTP{
+			Port:            "8080",
+			ShutdownTimeout: 10 * time.Second,
+			APIKeysReload:   10 * time.Second,
+			MaxRedirects:    5,
+		},
+		Admin: Admin{
+			Port: "8081",
+		},
+		Download: Download{
+			Dir:      ".",
+			Template: "{{.Parent}}/{{.ID}}{{.Ext}}",
+			Parallel: 4,
+		},
+		Downloader: Downloader{
+			MaxSize:      200 << 20,
+			AllowedTypes: []string{"image/", "video/"},
+			PartsDir:     filepath.Join(os.TempDir(), "imgur-fetcher"),
+			HLSParallel:  4,
+			HLSRetries:   3,
+			HLSSelect:    HLSSelectBandwidth,
+		},
+		Storage: Storage{
+			Backend: StorageLocal,
+			Dir:     "media",
+			Layout:  "{{.Parent}}/{{.Name}}",
+		},
+		Idempotency: Idempotency{
+			Backend: IdempotencyMemory,
+			TTL:     24 * time.Hour,
+			Size:    100_000,
+			Path:    "idempotency.db",
+		},
+		Cache: Cache{
+			Backend:     CacheMemory,
+			TTL:         time.Hour,
+			NotFoundTTL: 5 * time.Minute,
+			Size:        10_000,
+			Dir:         filepath.Join(os.TempDir(), "imgur-fetcher-cache"),
+		},
+		Variant: Variant{
+			Policy: VariantMP4,
+		},
+	}
+}
+
+func Load(name string, args []string, lookup Lookup) (Config, []string, error) {
+	cfg := Default()
+	fields := cfg.fields()
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flagValues := map[string]string{}
+	for _, f := range fields {
+		f := f
+		if f.flag == "" {
+			continue
+		}
+
+		usage := f.tag.Get("usage")
+		if f.env != "" {
+			usage = fmt.Sprintf("%s (env %s)", usage, f.env)
+		}
+
+		if f.value.Kind() == reflect.Bool {
+			flags.BoolFunc(f.flag, usage, func(value string) error {
+				flagValues[f.flag] = value
+				return nil
+			})
+			continue
+		}
+
+		flags.Func(f.flag, usage, func(value string) error {
+			flagValues[f.flag] = value
+			return nil
+		})
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	configFile, _ := lookup("CONFIG_FILE")
+	if value, ok := flagValues["config"]; ok {
+		configFile = value
+	}
+
+	if configFile != "" {
+		content, err := os.ReadFile(configFile)
+		if err != nil {
+			return Config{}, nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		if err := yaml.Unmarshal(content, &cfg); err != nil {
+			return Config{}, nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
+
+	for _, f := range fields {
+		value, source, ok, err := f.fromEnv(lookup)
+		if err != nil {
+			return Config{}, nil, err
+		}
+
+		if flagValue, set := flagValues[f.flag]; set && f.flag != "" {
+			value, source, ok = flagValue, "--"+f.flag, true
+		}
+
+		if !ok {
+			continue
+		}
+
+		if err := set(f.value, value); err != nil {
+			return Config{}, nil, fmt.Errorf("%w: %s from %s: %v", ErrInvalid, f.path, source, err)
+		}
+	}
+
+	return cfg, flags.Args(), nil
+}
+
+func (c Config) Validate(required ...string) error {
+	byPath := map[string]field{}
+	for _, f := range c.fields() {
+		byPath[f.path] = f
+	}
+
+	var errs []error
+	seen := map[string]bool{}
+	for _, path := range required {
+		if seen[path] {
+			continue
+		}
+
+		seen[path] = true
+		f, ok := byPath[path]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: unknown field %s", ErrInvalid, path))
+			continue
+		}
+
+		if f.value.IsZero() {
+			errs = append(errs, fmt.Errorf("%w: %s is required (set %s, %s_FILE or --%s)", ErrInvalid, f.path, f.env, f.env, f.flag))
+		}
+	}
+
+	if _, err := strconv.Atoi(c.HTTP.Port); c.HTTP.Port != "" && err != nil {
+		errs = append(errs, fmt.Errorf("%w: http.port must be numeric", ErrInvalid))
+	}
+
+	if _, err := strconv.Atoi(c.Admin.Port); c.Admin.Port != "" && err != nil {
+		errs = append(errs, fmt.Errorf("%w: admin.port must be numeric", ErrInvalid))
+	}
+
+	if c.Imgur.MaxPages < 1 {
+		errs = append(errs, fmt.Errorf("%w: imgur.max_pages must be at least 1", ErrInvalid))
+	}
+
+	if c.Download.Parallel < 1 {
+		errs = append(errs, fmt.Errorf("%w: download.parallel must be at least 1", ErrInvalid))
+	}
+
+	switch c.Storage.Backend {
+	case StorageLocal, StorageS3, StorageWebDAV, StorageMemory:
+	default:
+		errs = append(errs, fmt.Errorf("%w: storage.backend must be one of %s, %s, %s or %s", ErrInvalid, StorageLocal, StorageS3, StorageWebDAV, StorageMemory))
+	}
+
+	switch c.Idempotency.Backend {
+	case IdempotencyNone, IdempotencyMemory, IdempotencySQLite:
+	case IdempotencyRedis:
+		if c.Idempotency.RedisURL == "" {
+			errs = append(errs, fmt.Errorf("%w: idempotency.redis_url is required by the %s backend", ErrInvalid, IdempotencyRedis))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("%w: idempotency.backend must be one of %s, %s, %s or %s", ErrInvalid, IdempotencyMemory, IdempotencySQLite, IdempotencyRedis, IdempotencyNone))
+	}
+
+	switch c.Cache.Backend {
+	case CacheNone, CacheMemory, CacheDisk, CacheShared:
+	default:
+		errs = append(errs, fmt.Errorf("%w: cache.backend must be one of %s, %s, %s or %s", ErrInvalid, CacheMemory, CacheDisk, CacheShared, CacheNone))
+	}
+
+	if c.Downloader.HLSParallel < 1 {
+		errs = append(errs, fmt.Errorf("%w: downloader.hls_parallel must be at least 1", ErrInvalid))
+	}
+
+	switch c.Downloader.HLSSelect {
+	case HLSSelectBandwidth, HLSSelectResolution:
+	default:
+		errs = append(errs, fmt.Errorf("%w: downloader.hls_select must be %s or %s", ErrInvalid, HLSSelectBandwidth, HLSSelectResolution))
+	}
+
+	switch c.Variant.Policy {
+	case VariantMP4, VariantSmallest, VariantOriginal, VariantAll:
+	default:
+		errs = append(errs, fmt.Errorf("%w: variant.policy must be one of %s, %s, %s or %s", ErrInvalid, VariantMP4, VariantSmallest, VariantOriginal, VariantAll))
+	}
+
+	if c.Variant.MaxSize < 0 {
+		errs = append(errs, fmt.Errorf("%w: variant.max_size must not be negative", ErrInvalid))
+	}
+
+	switch c.Broker {
+	case BrokerRabbitMQ, BrokerMemory:
+	default:
+		errs = append(errs, fmt.Errorf("%w: broker must be either %s or %s", ErrInvalid, BrokerRabbitMQ, BrokerMemory))
+	}
+
+	switch strings.ToLower(c.LogLevel) {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("%w: log_level must be one of debug, info, warn or error", ErrInvalid))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Config) Print(w io.Writer) error {
+	copied := c
+	for _, f := range copied.fields() {
+		if f.tag.Get("secret") == "true" && !f.value.IsZero() {
+			f.value.SetString(redacted)
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	return encoder.Encode(copied)
+}
+
+func (c *Config) fields() []field {
+	return walk(reflect.ValueOf(c).Elem(), "")
+}
+
+func (f field) fromEnv(lookup Lookup) (string, string, bool, error) {
+	if f.env == "" {
+		return "", "", false, nil
+	}
+
+	if value, ok := lookup(f.env); ok && value != "" {
+		return value, f.env, true, nil
+	}
+
+	path, ok := lookup(f.env + "_FILE")
+	if !ok || path == "" {
+		return "", "", false, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", false, fmt.Errorf("failed to read %s_FILE: %w", f.env, err)
+	}
+
+	return strings.TrimSpace(string(content)), f.env + "_FILE", true, nil
+}
+
+func walk(v reflect.Value, prefix string) []field {
+	var fields []field
+	for i := 0; i < v.NumField(); i++ {
+		structField := v.Type().Field(i)
+		name, _, _ := strings.Cut(structField.Tag.Get("yaml"), ",")
+		if name == "-" || name == "" {
+			name = strings.ToLower(structField.Name)
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		if structField.Type.Kind() == reflect.Struct && structField.Type != reflect.TypeOf(time.Duration(0)) {
+			fields = append(fields, walk(v.Field(i), path)...)
+			continue
+		}
+
+		fields = append(fields, field{
+			path:  path,
+			env:   structField.Tag.Get("env"),
+			flag:  structField.Tag.Get("flag"),
+			value: v.Field(i),
+			tag:   structField.Tag,
+		})
+	}
+
+	return fields
+}
+
+func set(v reflect.Value, value string) error {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Int, v.Kind() == reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(i)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
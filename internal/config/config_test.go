@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "client-id")
+	if err := os.WriteFile(secretFile, []byte("secret-client-id\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	configFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configFile, []byte("log_level: debug\nhttp:\n  port: \"9000\"\n  shutdown_timeout: 3s\nrabbitmq:\n  url: amqp://file\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	env := map[string]string{
+		"CONFIG_FILE":          configFile,
+		"IMGUR_CLIENT_ID_FILE": secretFile,
+		"RABBITMQ_URL":         "amqp://env",
+		"ALLOWED_HOSTS":        "imgur.com, example.com",
+	}
+
+	cfg, args, err := Load("test", []string{"--port", "9100", "https://imgur.com/some-id"}, func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{name: "Should read values from the config file", got: cfg.LogLevel, want: "debug"},
+		{name: "Should parse durations from the config file", got: cfg.HTTP.ShutdownTimeout, want: 3 * time.Second},
+		{name: "Should prefer environment over the config file", got: cfg.RabbitMQ.URL, want: "amqp://env"},
+		{name: "Should prefer flags over everything else", got: cfg.HTTP.Port, want: "9100"},
+		{name: "Should read secrets from _FILE variables", got: cfg.Imgur.ClientID, want: "secret-client-id"},
+		{name: "Should split lists", got: strings.Join(cfg.HTTP.AllowedHosts, "|"), want: "imgur.com|example.com"},
+		{name: "Should keep defaults", got: cfg.RabbitMQ.FetcherQueue, want: "fetcher.imgur"},
+		{name: "Should return positional arguments", got: strings.Join(args, "|"), want: "https://imgur.com/some-id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got = %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := Default()
+	err := cfg.Validate("imgur.client_id", "rabbitmq.url")
+	if !errors.Is(err, ErrInvalid) {
+		t.Fatalf("Validate() error = %v, wantErr %v", err, ErrInvalid)
+	}
+
+	if !strings.Contains(err.Error(), "IMGUR_CLIENT_ID") || !strings.Contains(err.Error(), "RABBITMQ_URL") {
+		t.Errorf("Validate() error = %v, want every missing field", err)
+	}
+
+	cfg.Imgur.ClientID = "some-client-id"
+	cfg.RabbitMQ.URL = "amqp://localhost"
+	if err := cfg.Validate("imgur.client_id", "rabbitmq.url"); err != nil {
+		t.Errorf("Validate() error = %v, wantErr %v", err, nil)
+	}
+}
+
+func TestConfig_Print(t *testing.T) {
+	cfg := Default()
+	cfg.Imgur.ClientID = "some-client-id"
+
+	var buffer bytes.Buffer
+	if err := cfg.Print(&buffer); err != nil {
+		t.Fatalf("Print() error = %v", err)
+	}
+
+	if strings.Contains(buffer.String(), "some-client-id") || !strings.Contains(buffer.String(), redacted) {
+		t.Errorf("Print() = %s, want secrets redacted", buffer.String())
+	}
+
+	if cfg.Imgur.ClientID != "some-client-id" {
+		t.Errorf("Print() changed the original config")
+	}
+}
@@ -3,42 +3,96 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/pkg/apikey"
+	"github.com/alancesar/imgur-fetcher/pkg/httpcache"
 	"github.com/alancesar/imgur-fetcher/pkg/imgur"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
 	"github.com/alancesar/imgur-fetcher/pkg/media"
+	"github.com/alancesar/imgur-fetcher/pkg/resolver"
+	"github.com/alancesar/imgur-fetcher/pkg/urlpolicy"
+	"github.com/alancesar/imgur-fetcher/pkg/validation"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultMaxPages = 1
+	searchParent    = "search"
 )
 
 type (
 	Client interface {
-		GetMediaByURL(url string) ([]imgur.Media, error)
+		Resolve(ctx context.Context, rawURL string, options resolver.Options) ([]resolver.Media, error)
+	}
+
+	Searcher interface {
+		SearchItems(ctx context.Context, query imgur.SearchQuery, options imgur.GalleryOptions, page int) ([]imgur.GalleryItem, error)
+		GalleryItemMedia(ctx context.Context, item imgur.GalleryItem) ([]imgur.Media, error)
 	}
 
 	Publisher interface {
-		Publish(ctx context.Context, req media.Media) error
+		Send(ctx context.Context, v any) error
 	}
 
 	Controller struct {
 		httpClient *http.Client
 		client     Client
+		searcher   Searcher
 		publisher  Publisher
+		policy     resolver.Policy
+		maxPages   int
+		logger     *slog.Logger
+	}
+
+	PublishRequest struct {
+		URL      string   `json:"url"`
+		Parent   []string `json:"parent"`
+		Sort     string   `json:"sort,omitempty"`
+		Window   string   `json:"window,omitempty"`
+		MaxPages int      `json:"max_pages,omitempty"`
 	}
 
 	Response struct {
 		URLs []string `json:"urls"`
 	}
+
+	SearchResponse struct {
+		Media    []resolver.Media `json:"media"`
+		Enqueued int              `json:"enqueued"`
+	}
+
+	ErrorResponse struct {
+		Error    string                  `json:"error"`
+		Details  []validation.FieldError `json:"details,omitempty"`
+		Enqueued int                     `json:"enqueued,omitempty"`
+	}
 )
 
-func New(httpClient *http.Client, client Client, publisher Publisher) *Controller {
+func New(httpClient *http.Client, client Client, searcher Searcher, publisher Publisher, policy resolver.Policy, maxPages int, logger *slog.Logger) *Controller {
 	return &Controller{
 		httpClient: httpClient,
 		client:     client,
+		searcher:   searcher,
 		publisher:  publisher,
+		policy:     policy,
+		maxPages:   maxPages,
+		logger:     logger,
 	}
 }
 
 func (c Controller) GetMediaByURL(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		URL string `json:"url"`
+		URL      string `json:"url"`
+		Variant  string `json:"variant"`
+		MaxSize  int64  `json:"max_size"`
+		Sort     string `json:"sort"`
+		Window   string `json:"window"`
+		MaxPages int    `json:"max_pages"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -46,16 +100,51 @@ func (c Controller) GetMediaByURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	m, err := c.client.GetMediaByURL(req.URL)
+	normalized, err := validation.Media(media.Media{URL: req.URL})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	policy, err := c.requestPolicy(req.Variant, req.MaxSize)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, validation.Errors{
+			{Field: "variant", Code: validation.CodeInvalid, Message: err.Error()},
+		})
+		return
+	}
+
+	gallery, err := c.galleryOptions(req.Sort, req.Window, syncMaxPages(req.MaxPages))
 	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := logging.With(r.Context(), logging.KeyURL, normalized.URL)
+	if strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+		ctx = httpcache.WithNoCache(ctx)
+	}
+
+	m, err := c.client.Resolve(ctx, normalized.URL, resolver.Options{
+		Sort:     gallery.Sort,
+		Window:   gallery.Window,
+		MaxPages: gallery.MaxPages,
+	})
+	if errors.Is(err, resolver.ErrUnsupported) {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	} else if err != nil {
+		c.logger.ErrorContext(ctx, "failed to resolve media", logging.KeyError, err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	m = policy.Apply(m)
+
 	var response Response
 	response.URLs = make([]string, len(m), len(m))
 	for i, m := range m {
-		response.URLs[i] = m.HigherQualityURL()
+		response.URLs[i] = m.URL
 	}
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -67,28 +156,283 @@ func (c Controller) GetMediaByURL(w http.ResponseWriter, r *http.Request) {
 }
 
 func (c Controller) PublishMedia(w http.ResponseWriter, r *http.Request) {
-	var m media.Media
-	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+	var body PublishRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	m, err := validation.Media(media.Media{URL: body.URL, Parent: body.Parent})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	gallery, err := c.galleryOptions(body.Sort, body.Window, body.MaxPages)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := logging.With(r.Context(), logging.KeyURL, m.URL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, m.URL, nil)
+	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	res, err := c.httpClient.Head(m.URL)
+	res, err := c.httpClient.Do(req)
 	if err != nil {
+		if errors.Is(err, urlpolicy.ErrRejected) {
+			c.logger.WarnContext(ctx, "url rejected by policy", logging.KeyError, err)
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		c.logger.ErrorContext(ctx, "failed to probe url", logging.KeyError, err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	_ = res.Body.Close()
+
 	if res.StatusCode >= http.StatusBadRequest {
 		w.WriteHeader(res.StatusCode)
 		return
 	}
 
-	m.URL = res.Request.URL.String()
-	if err := c.publisher.Publish(r.Context(), m); err != nil {
+	post := media.Post{
+		URL:      res.Request.URL.String(),
+		Parent:   m.Parent,
+		Sort:     gallery.Sort,
+		Window:   gallery.Window,
+		MaxPages: gallery.MaxPages,
+	}
+
+	if err := c.publisher.Send(ctx, post); err != nil {
+		c.logger.ErrorContext(ctx, "failed to publish media", logging.KeyError, err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusAccepted)
 }
+
+func (c Controller) Search(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	query, err := imgur.ParseSearchQuery(values)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, validation.Errors{
+			{Field: searchField(query), Code: validation.CodeInvalid, Message: err.Error()},
+		})
+		return
+	}
+
+	var errs validation.Errors
+	page, err := intParam(values, "page", 0)
+	errs = appendErrors(errs, err)
+	maxPages, err := intParam(values, "max_pages", 0)
+	errs = appendErrors(errs, err)
+	maxSize, err := intParam(values, "max_size", 0)
+	errs = appendErrors(errs, err)
+	enqueue, err := boolParam(values, "enqueue")
+	errs = appendErrors(errs, err)
+	parent, err := validation.SanitizeParent(values["parent"])
+	if err != nil {
+		errs = append(errs, validation.AsFieldErrors("parent", err)...)
+	} else if len(parent) == 0 {
+		parent = []string{searchParent}
+	}
+
+	if len(errs) > 0 {
+		writeError(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	policy, err := c.requestPolicy(values.Get("variant"), maxSize)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, validation.Errors{
+			{Field: "variant", Code: validation.CodeInvalid, Message: err.Error()},
+		})
+		return
+	}
+
+	gallery, err := c.galleryOptions(values.Get("sort"), values.Get("window"), syncMaxPages(int(maxPages)))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if enqueue {
+		if key, ok := apikey.FromContext(r.Context()); !ok || !key.HasScope(apikey.ScopePublish) {
+			writeError(w, http.StatusForbidden, errors.New("enqueue requires the publish scope"))
+			return
+		}
+	}
+
+	ctx := r.Context()
+	if strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+		ctx = httpcache.WithNoCache(ctx)
+	}
+
+	hits, err := c.searcher.SearchItems(ctx, query, gallery, int(page))
+	if err != nil {
+		c.logger.ErrorContext(ctx, "failed to search", logging.KeyError, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var response SearchResponse
+	var resolved []resolver.Media
+	for _, hit := range hits {
+		images, err := c.searcher.GalleryItemMedia(ctx, hit)
+		if err != nil {
+			c.logger.ErrorContext(ctx, "failed to expand search hit", logging.KeyImgurID, hit.ID, logging.KeyError, err)
+			writeSearchError(w, "failed to expand search hit "+hit.ID, response.Enqueued)
+			return
+		}
+
+		for _, image := range images {
+			resolved = append(resolved, image.Resolved())
+		}
+
+		if !enqueue {
+			continue
+		}
+
+		m, err := validation.Media(media.Media{URL: hit.Link, Parent: parent})
+		if err != nil {
+			c.logger.WarnContext(ctx, "skipping invalid search hit", logging.KeyImgurID, hit.ID, logging.KeyError, err)
+			continue
+		}
+
+		post := media.Post{
+			URL:     m.URL,
+			Parent:  m.Parent,
+			Variant: values.Get("variant"),
+			MaxSize: maxSize,
+		}
+
+		if err := c.publisher.Send(logging.With(ctx, logging.KeyURL, m.URL), post); err != nil {
+			c.logger.ErrorContext(ctx, "failed to publish search hit", logging.KeyImgurID, hit.ID, logging.KeyError, err)
+			writeSearchError(w, "failed to enqueue search hit "+hit.ID, response.Enqueued)
+			return
+		}
+
+		response.Enqueued++
+	}
+
+	response.Media = policy.Apply(resolved)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c Controller) requestPolicy(variant string, maxSize int64) (resolver.Policy, error) {
+	if variant == "" && maxSize == 0 {
+		return c.policy, nil
+	}
+
+	if variant == "" {
+		variant = c.policy.Name
+	}
+
+	return resolver.ParsePolicy(variant, maxSize)
+}
+
+func (c Controller) galleryOptions(sort, window string, maxPages int) (imgur.GalleryOptions, error) {
+	options, err := imgur.ParseGalleryOptions(sort, window, maxPages, c.maxPages)
+	if err == nil {
+		return options, nil
+	}
+
+	field := "max_pages"
+	if sort != "" && !slices.Contains(imgur.Sorts, strings.ToLower(sort)) {
+		field = "sort"
+	} else if window != "" && !slices.Contains(imgur.Windows, strings.ToLower(window)) {
+		field = "window"
+	}
+
+	return imgur.GalleryOptions{}, validation.Errors{{Field: field, Code: validation.CodeInvalid, Message: err.Error()}}
+}
+
+func syncMaxPages(maxPages int) int {
+	if maxPages == 0 {
+		return defaultMaxPages
+	}
+
+	return maxPages
+}
+
+func searchField(query imgur.SearchQuery) string {
+	switch {
+	case query.Type != "" && !slices.Contains(imgur.SearchTypes, query.Type):
+		return "q_type"
+	case query.SizePx != "" && !slices.Contains(imgur.SearchSizes, query.SizePx):
+		return "q_size_px"
+	default:
+		return "q"
+	}
+}
+
+func intParam(values url.Values, field string, fallback int64) (int64, error) {
+	raw := values.Get(field)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n < 0 {
+		return 0, validation.Errors{{Field: field, Code: validation.CodeInvalid, Message: field + " must be a non-negative integer"}}
+	}
+
+	return n, nil
+}
+
+func boolParam(values url.Values, field string) (bool, error) {
+	raw := values.Get(field)
+	if raw == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, validation.Errors{{Field: field, Code: validation.CodeInvalid, Message: field + " must be a boolean"}}
+	}
+
+	return b, nil
+}
+
+func appendErrors(errs validation.Errors, err error) validation.Errors {
+	var fieldErrors validation.Errors
+	if errors.As(err, &fieldErrors) {
+		return append(errs, fieldErrors...)
+	}
+
+	return errs
+}
+
+func writeSearchError(w http.ResponseWriter, message string, enqueued int) {
+	w.WriteHeader(http.StatusInternalServerError)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{
+		Error:    message,
+		Enqueued: enqueued,
+	})
+}
+
+func writeError(w http.ResponseWriter, statusCode int, err error) {
+	response := ErrorResponse{
+		Error: err.Error(),
+	}
+
+	var validationErrors validation.Errors
+	if errors.As(err, &validationErrors) {
+		response.Error = "invalid request"
+		response.Details = validationErrors
+	}
+
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(response)
+}
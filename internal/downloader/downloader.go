@@ -0,0 +1,476 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/internal/app"
+	"github.com/alancesar/imgur-fetcher/pkg/dedup"
+	"github.com/alancesar/imgur-fetcher/pkg/hls"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"github.com/alancesar/imgur-fetcher/pkg/media"
+	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"github.com/alancesar/imgur-fetcher/pkg/storage"
+	"github.com/alancesar/imgur-fetcher/pkg/urlpolicy"
+	"github.com/alancesar/imgur-fetcher/pkg/validation"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	Required = []string{"admin.port"}
+
+	ErrUnsupportedType = errors.New("unsupported content type")
+	ErrTooLarge        = errors.New("file too large")
+	ErrLengthMismatch  = errors.New("downloaded length does not match the expected length")
+)
+
+type (
+	Events interface {
+		Send(ctx context.Context, v any) error
+	}
+
+	Options struct {
+		MaxSize      int64
+		AllowedTypes []string
+		Layout       *storage.Layout
+		PartsDir     string
+		Index        dedup.Index
+		Streams      *hls.Fetcher
+	}
+
+	Downloader struct {
+		httpClient *http.Client
+		backend    storage.Backend
+		events     Events
+		options    Options
+		logger     *slog.Logger
+	}
+
+	limitedReader struct {
+		reader    io.Reader
+		remaining int64
+	}
+)
+
+func New(httpClient *http.Client, backend storage.Backend, events Events, options Options, logger *slog.Logger) *Downloader {
+	if options.Layout == nil {
+		options.Layout, _ = storage.NewLayout(storage.DefaultLayout)
+	}
+
+	if options.PartsDir == "" {
+		options.PartsDir = filepath.Join(os.TempDir(), "imgur-fetcher")
+	}
+
+	if options.Streams == nil {
+		options.Streams = hls.New(httpClient, hls.Options{})
+	}
+
+	return &Downloader{
+		httpClient: httpClient,
+		backend:    backend,
+		events:     events,
+		options:    options,
+		logger:     logger,
+	}
+}
+
+func Setup(_ context.Context, a *app.App) error {
+	cfg := a.Config
+	events, err := a.Publisher(cfg.RabbitMQ.CompletedExchange, cfg.RabbitMQ.CompletedKey)
+	if err != nil {
+		return err
+	}
+
+	backend, err := a.Storage()
+	if err != nil {
+		return err
+	}
+
+	layout, err := storage.NewLayout(cfg.Storage.Layout)
+	if err != nil {
+		return err
+	}
+
+	index, err := a.Index()
+	if err != nil {
+		return err
+	}
+
+	d := New(a.PolicyClient(), backend, events, Options{
+		MaxSize:      cfg.Downloader.MaxSize,
+		AllowedTypes: cfg.Downloader.AllowedTypes,
+		Layout:       layout,
+		PartsDir:     cfg.Downloader.PartsDir,
+		Index:        index,
+		Streams: hls.New(a.PolicyClient(), hls.Options{
+			Parallel:     cfg.Downloader.HLSParallel,
+			Retries:      cfg.Downloader.HLSRetries,
+			Select:       cfg.Downloader.HLSSelect,
+			MaxBandwidth: cfg.Downloader.HLSMaxBandwidth,
+			MaxHeight:    cfg.Downloader.HLSMaxHeight,
+		}),
+	}, a.Logger)
+
+	if err := a.Subscribe(cfg.RabbitMQ.DownloadsQueue, d.HandleMessage); err != nil {
+		return err
+	}
+
+	a.ServeAdmin()
+	return nil
+}
+
+func (d Downloader) HandleMessage(ctx context.Context, body []byte) error {
+	var m media.Media
+	if err := json.Unmarshal(body, &m); err != nil {
+		return fmt.Errorf("%w: failed to unmarshal message: %v", app.ErrPermanent, err)
+	}
+
+	ctx = logging.With(ctx, logging.KeyURL, m.URL)
+	completed, err := d.Download(ctx, m)
+	if errors.Is(err, status.ErrNotFound) {
+		d.logger.WarnContext(ctx, "media not found, skipping", logging.KeyError, err)
+		return nil
+	} else if errors.Is(err, ErrUnsupportedType) || errors.Is(err, ErrTooLarge) || errors.Is(err, storage.ErrInvalidKey) ||
+		errors.Is(err, hls.ErrInvalidPlaylist) || errors.Is(err, hls.ErrEncrypted) || errors.Is(err, hls.ErrSeparateAudio) || errors.Is(err, urlpolicy.ErrRejected) {
+		return fmt.Errorf("%w: %w", app.ErrPermanent, err)
+	} else if err != nil {
+		return err
+	}
+
+	if err := d.events.Send(ctx, completed); err != nil {
+		return fmt.Errorf("failed to publish completion: %w", err)
+	}
+
+	return nil
+}
+
+func (d Downloader) Download(ctx context.Context, m media.Media) (media.Completed, error) {
+	var stream *hls.Stream
+	if hls.IsPlaylist(m.URL) {
+		opened, err := d.options.Streams.Open(ctx, m.URL)
+		if err != nil {
+			return media.Completed{}, fmt.Errorf("failed to open stream: %w", err)
+		}
+
+		stream = &opened
+	}
+
+	key, err := d.key(m, stream)
+	if err != nil {
+		return media.Completed{}, err
+	}
+
+	if info, err := d.backend.Stat(ctx, key); err == nil {
+		d.logger.DebugContext(ctx, "media already stored, skipping", "path", key)
+		return completed(m, info, ""), nil
+	} else if !errors.Is(err, storage.ErrNotExist) {
+		return media.Completed{}, err
+	}
+
+	if entry, ok := d.known(ctx, m); ok {
+		if err := storage.Link(ctx, d.backend, dedup.BlobKey(entry.Hash), key); err == nil {
+			d.logger.InfoContext(ctx, "media already downloaded, linked", "path", key, "sha256", entry.Hash)
+			return completed(m, storage.Info{Key: key, Size: entry.Size, ContentType: entry.ContentType}, entry.Hash), nil
+		} else if !errors.Is(err, storage.ErrNotExist) {
+			return media.Completed{}, err
+		}
+	}
+
+	p := newPart(d.options.PartsDir, key)
+	var size int64
+	if stream != nil {
+		size, err = d.fetchStream(ctx, m, *stream, p)
+	} else {
+		size, err = d.fetch(ctx, m, p)
+	}
+
+	if err != nil {
+		return media.Completed{}, err
+	}
+
+	if err := d.store(ctx, m, key, p, size); err != nil {
+		return media.Completed{}, fmt.Errorf("failed to store media: %w", err)
+	}
+
+	p.remove()
+	d.logger.InfoContext(ctx, "media downloaded", "path", key, "size", size, "sha256", p.hash)
+	return completed(m, storage.Info{Key: key, Size: size, ContentType: p.meta.ContentType}, p.hash), nil
+}
+
+func (d Downloader) known(ctx context.Context, m media.Media) (dedup.Entry, bool) {
+	if d.options.Index == nil || m.ID == "" {
+		return dedup.Entry{}, false
+	}
+
+	entry, err := d.options.Index.ByID(ctx, m.ID)
+	if err != nil {
+		if !errors.Is(err, dedup.ErrNotFound) {
+			d.logger.WarnContext(ctx, "failed to look up the dedup index", logging.KeyError, err)
+		}
+
+		return dedup.Entry{}, false
+	}
+
+	return entry, entry.URL == "" || entry.URL == m.URL
+}
+
+func (d Downloader) store(ctx context.Context, m media.Media, key string, p *part, size int64) error {
+	target := key
+	if d.options.Index != nil {
+		target = dedup.BlobKey(p.hash)
+		if _, err := d.backend.Stat(ctx, target); err == nil {
+			d.logger.DebugContext(ctx, "blob already stored", "sha256", p.hash)
+			return d.link(ctx, m, key, p, size)
+		} else if !errors.Is(err, storage.ErrNotExist) {
+			return err
+		}
+	}
+
+	file, err := p.open()
+	if err != nil {
+		return err
+	}
+
+	err = d.backend.Put(ctx, target, file, storage.Info{
+		Key:         target,
+		Size:        size,
+		ContentType: p.meta.ContentType,
+	})
+	_ = file.Close()
+	if err != nil || d.options.Index == nil {
+		return err
+	}
+
+	return d.link(ctx, m, key, p, size)
+}
+
+func (d Downloader) link(ctx context.Context, m media.Media, key string, p *part, size int64) error {
+	if err := storage.Link(ctx, d.backend, dedup.BlobKey(p.hash), key); err != nil {
+		return err
+	}
+
+	return d.options.Index.Put(ctx, dedup.Entry{
+		ID:          m.ID,
+		Hash:        p.hash,
+		URL:         m.URL,
+		Size:        size,
+		ContentType: p.meta.ContentType,
+	})
+}
+
+func (d Downloader) fetch(ctx context.Context, m media.Media, p *part) (int64, error) {
+	offset := p.resume(m.URL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.URL, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Accept-Encoding", "identity")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", p.validator())
+	}
+
+	res, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	total := int64(-1)
+	switch res.StatusCode {
+	case http.StatusOK:
+		offset, total = 0, res.ContentLength
+		p.meta = partMeta{
+			URL:          m.URL,
+			ETag:         res.Header.Get("ETag"),
+			LastModified: res.Header.Get("Last-Modified"),
+			ContentType:  res.Header.Get("Content-Type"),
+		}
+	case http.StatusPartialContent:
+		start, size, ok := contentRange(res.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			p.remove()
+			return 0, fmt.Errorf("%w: unexpected Content-Range %q for offset %d", status.ErrBadStatus, res.Header.Get("Content-Range"), offset)
+		}
+
+		total = size
+		d.logger.InfoContext(ctx, "resuming download", "offset", offset)
+	case http.StatusRequestedRangeNotSatisfiable:
+		if _, size, ok := contentRange(res.Header.Get("Content-Range")); ok && size == offset {
+			_, err := p.write(http.NoBody, offset)
+			return offset, err
+		}
+
+		p.remove()
+		return 0, fmt.Errorf("%w: %d (%s): %s", status.ErrBadStatus, res.StatusCode, res.Status, m.URL)
+	case http.StatusNotFound, http.StatusGone:
+		p.remove()
+		return 0, fmt.Errorf("%w: %s", status.ErrNotFound, m.URL)
+	default:
+		return 0, fmt.Errorf("%w: %d (%s): %s", status.ErrBadStatus, res.StatusCode, res.Status, m.URL)
+	}
+
+	if !d.allowed(p.meta.ContentType) {
+		p.remove()
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedType, p.meta.ContentType)
+	}
+
+	expected := total
+	if expected < 0 && m.Size > 0 {
+		expected = m.Size
+	}
+
+	if d.options.MaxSize > 0 && expected > d.options.MaxSize {
+		p.remove()
+		return 0, fmt.Errorf("%w: %d bytes", ErrTooLarge, expected)
+	}
+
+	if err := p.save(); err != nil {
+		return 0, err
+	}
+
+	var body io.Reader = res.Body
+	if d.options.MaxSize > 0 {
+		body = &limitedReader{reader: res.Body, remaining: d.options.MaxSize - offset}
+	}
+
+	written, err := p.write(body, offset)
+	if errors.Is(err, ErrTooLarge) {
+		p.remove()
+		return 0, err
+	} else if err != nil {
+		return 0, fmt.Errorf("download interrupted after %d bytes: %w", offset+written, err)
+	}
+
+	if size := offset + written; expected >= 0 && size != expected {
+		p.remove()
+		return 0, fmt.Errorf("%w: got %d bytes, want %d", ErrLengthMismatch, size, expected)
+	}
+
+	return offset + written, nil
+}
+
+func (d Downloader) fetchStream(ctx context.Context, m media.Media, s hls.Stream, p *part) (int64, error) {
+	p.remove()
+	p.meta = partMeta{URL: m.URL, ContentType: s.ContentType()}
+	if !d.allowed(p.meta.ContentType) {
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedType, p.meta.ContentType)
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		_, err := d.options.Streams.Write(ctx, s, writer)
+		_ = writer.CloseWithError(err)
+	}()
+
+	var body io.Reader = reader
+	if d.options.MaxSize > 0 {
+		body = &limitedReader{reader: reader, remaining: d.options.MaxSize}
+	}
+
+	written, err := p.write(body, 0)
+	_ = reader.Close()
+	if err != nil {
+		p.remove()
+		if errors.Is(err, ErrTooLarge) {
+			return 0, err
+		}
+
+		return 0, fmt.Errorf("stream interrupted after %d bytes: %w", written, err)
+	}
+
+	d.logger.DebugContext(ctx, "stream downloaded", "segments", len(s.Playlist.Segments), "bandwidth", s.Variant.Bandwidth)
+	return written, nil
+}
+
+func (d Downloader) Key(m media.Media) (string, error) {
+	return d.key(m, nil)
+}
+
+func (d Downloader) key(m media.Media, stream *hls.Stream) (string, error) {
+	parent, err := validation.SanitizeParent(m.Parent)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", storage.ErrInvalidKey, err)
+	}
+
+	item, err := storage.NewItem(media.Media{URL: m.URL, Parent: parent})
+	if err != nil {
+		return "", err
+	}
+
+	if stream != nil {
+		item.ID = m.ID
+		if parsed, err := url.Parse(m.URL); err == nil && item.ID == "" {
+			item.ID = path.Base(path.Dir(parsed.Path))
+		}
+
+		item.Ext = stream.Ext()
+		item.Name = item.ID + item.Ext
+	}
+
+	return d.options.Layout.Key(item)
+}
+
+func (d Downloader) allowed(contentType string) bool {
+	if len(d.options.AllowedTypes) == 0 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range d.options.AllowedTypes {
+		if mediaType == allowed || (strings.HasSuffix(allowed, "/") && strings.HasPrefix(mediaType, allowed)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func completed(m media.Media, info storage.Info, hash string) media.Completed {
+	return media.Completed{
+		ID:          m.ID,
+		URL:         m.URL,
+		Parent:      m.Parent,
+		Path:        info.Key,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		SHA256:      hash,
+		CompletedAt: time.Now().UTC(),
+	}
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, ErrTooLarge
+	}
+
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n, ErrTooLarge
+	}
+
+	return n, err
+}
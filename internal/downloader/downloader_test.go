@@ -0,0 +1,432 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/internal/app"
+	"github.com/alancesar/imgur-fetcher/internal/config"
+	"github.com/alancesar/imgur-fetcher/pkg/dedup"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"github.com/alancesar/imgur-fetcher/pkg/media"
+	"github.com/alancesar/imgur-fetcher/pkg/storage"
+	"github.com/alancesar/imgur-fetcher/pkg/urlpolicy"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type (
+	fakeEvents struct {
+		sent []any
+	}
+
+	roundTripFunc func(*http.Request) (*http.Response, error)
+)
+
+func (e *fakeEvents) Send(_ context.Context, v any) error {
+	e.sent = append(e.sent, v)
+	return nil
+}
+
+func TestDownloader_HandleMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/some-id.mp4":
+			w.Header().Set("Content-Type", "video/mp4")
+			_, _ = w.Write([]byte("some video"))
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte("<html></html>"))
+		case "/huge.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte(strings.Repeat("x", 64)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		httpClient *http.Client
+		body       string
+		wantErr    error
+		wantFile   string
+		wantEvent  bool
+	}{
+		{
+			name:      "Should store the file under its parent and emit a completion event",
+			body:      `{"url":"` + server.URL + `/some-id.mp4","parent":["u","someone"]}`,
+			wantFile:  "u/someone/some-id.mp4",
+			wantEvent: true,
+		},
+		{
+			name:    "Should discard unsupported content types",
+			body:    `{"url":"` + server.URL + `/page.html","parent":["u","someone"]}`,
+			wantErr: ErrUnsupportedType,
+		},
+		{
+			name:    "Should discard files larger than the limit",
+			body:    `{"url":"` + server.URL + `/huge.jpg","parent":["u","someone"]}`,
+			wantErr: ErrTooLarge,
+		},
+		{
+			name:    "Should discard unsafe parents",
+			body:    `{"url":"` + server.URL + `/some-id.mp4","parent":[".."]}`,
+			wantErr: storage.ErrInvalidKey,
+		},
+		{
+			name:       "Should discard URLs rejected by the policy",
+			httpClient: urlpolicy.Default().Client(nil),
+			body:       `{"url":"https://some-host.example.com/some-id.mp4","parent":["u","someone"]}`,
+			wantErr:    urlpolicy.ErrRejected,
+		},
+		{
+			name:    "Should discard malformed messages",
+			body:    `{`,
+			wantErr: app.ErrPermanent,
+		},
+		{
+			name: "Should skip missing media",
+			body: `{"url":"` + server.URL + `/missing.jpg","parent":["u","someone"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			events := &fakeEvents{}
+			httpClient := tt.httpClient
+			if httpClient == nil {
+				httpClient = server.Client()
+			}
+
+			d := New(httpClient, storage.NewLocal(dir), events, Options{
+				MaxSize:      32,
+				AllowedTypes: []string{"image/", "video/"},
+				PartsDir:     t.TempDir(),
+			}, logging.Discard())
+
+			err := d.HandleMessage(context.Background(), []byte(tt.body))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("HandleMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, app.ErrPermanent) {
+				t.Errorf("HandleMessage() error = %v, want a permanent failure", err)
+			}
+
+			if tt.wantFile != "" {
+				content, err := os.ReadFile(filepath.Join(dir, tt.wantFile))
+				if err != nil || string(content) != "some video" {
+					t.Errorf("stored file = %q, %v, want %q", content, err, "some video")
+				}
+			}
+
+			if got := len(events.sent) > 0; got != tt.wantEvent {
+				t.Fatalf("events sent = %v, want %v", events.sent, tt.wantEvent)
+			}
+
+			if tt.wantEvent {
+				completed := events.sent[0].(media.Completed)
+				if completed.Path != tt.wantFile || completed.Size != int64(len("some video")) || completed.ContentType != "video/mp4" {
+					t.Errorf("completion event = %+v", completed)
+				}
+			}
+
+			entries, _ := filepath.Glob(filepath.Join(dir, "*", "*", ".*.tmp"))
+			if len(entries) > 0 {
+				t.Errorf("temporary files left behind: %v", entries)
+			}
+		})
+	}
+}
+
+func TestDownloader_Download_Existing(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "u", "someone"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "u", "someone", "some-id.jpg"), []byte("stored"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		t.Fatal("should not download stored media")
+		return nil, nil
+	})}
+
+	d := New(client, storage.NewLocal(dir), &fakeEvents{}, Options{PartsDir: t.TempDir()}, logging.Discard())
+	completed, err := d.Download(context.Background(), media.Media{
+		URL:    "https://i.imgur.com/some-id.jpg",
+		Parent: []string{"u", "someone"},
+	})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+
+	if completed.Path != "u/someone/some-id.jpg" || completed.Size != int64(len("stored")) {
+		t.Errorf("Download() = %+v", completed)
+	}
+}
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestDownloader_Download_Resume(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+	interrupted := func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Header().Set("ETag", `"some-etag"`)
+		_, _ = w.Write(content[:400])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+
+	tests := []struct {
+		name      string
+		size      int64
+		retry     func(w http.ResponseWriter, r *http.Request)
+		wantRange string
+		wantErr   error
+	}{
+		{
+			name: "Should resume from the partial file",
+			retry: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "video/mp4")
+				w.Header().Set("ETag", `"some-etag"`)
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+			},
+			wantRange: "bytes=400-",
+		},
+		{
+			name: "Should fall back to a full download when ranges are ignored",
+			retry: func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "video/mp4")
+				_, _ = w.Write(content)
+			},
+			wantRange: "bytes=400-",
+		},
+		{
+			name: "Should start over when the file changed",
+			retry: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "video/mp4")
+				w.Header().Set("ETag", `"another-etag"`)
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+			},
+			wantRange: "bytes=400-",
+		},
+		{
+			name: "Should verify the length against the Imgur size",
+			size: int64(len(content)) + 1,
+			retry: func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "video/mp4")
+				w.(http.Flusher).Flush()
+				_, _ = w.Write(content)
+			},
+			wantErr: ErrLengthMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			var gotRange string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 && tt.wantErr == nil {
+					interrupted(w)
+					return
+				}
+
+				gotRange = r.Header.Get("Range")
+				tt.retry(w, r)
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			parts := t.TempDir()
+			d := New(server.Client(), storage.NewLocal(dir), &fakeEvents{}, Options{PartsDir: parts}, logging.Discard())
+			m := media.Media{URL: server.URL + "/some-id.mp4", Parent: []string{"u", "someone"}, Size: tt.size}
+
+			if tt.wantErr == nil {
+				if _, err := d.Download(context.Background(), m); err == nil {
+					t.Fatalf("Download() should fail on an interrupted transfer")
+				}
+			}
+
+			completed, err := d.Download(context.Background(), m)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Download() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if gotRange != tt.wantRange {
+				t.Errorf("Range = %q, want %q", gotRange, tt.wantRange)
+			}
+
+			if leftovers, _ := filepath.Glob(filepath.Join(parts, "*")); len(leftovers) > 0 {
+				t.Errorf("partial files left behind: %v", leftovers)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			stored, err := os.ReadFile(filepath.Join(dir, completed.Path))
+			if err != nil || !bytes.Equal(stored, content) {
+				t.Errorf("stored file has %d bytes, %v, want %d bytes", len(stored), err, len(content))
+			}
+		})
+	}
+}
+
+func TestDownloader_Download_Dedup(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte("same image"))
+	}))
+	defer server.Close()
+
+	backend := storage.NewMemory()
+	index := dedup.NewMemoryIndex()
+	d := New(server.Client(), backend, &fakeEvents{}, Options{PartsDir: t.TempDir(), Index: index}, logging.Discard())
+
+	downloads := []struct {
+		media        media.Media
+		wantRequests int
+	}{
+		{media: media.Media{URL: server.URL + "/first-id.jpg", Parent: []string{"r", "pics"}, ID: "first-id"}, wantRequests: 1},
+		{media: media.Media{URL: server.URL + "/second-id.jpg", Parent: []string{"u", "someone"}, ID: "second-id"}, wantRequests: 2},
+		{media: media.Media{URL: server.URL + "/first-id.jpg", Parent: []string{"u", "another"}, ID: "first-id"}, wantRequests: 2},
+		{media: media.Media{URL: server.URL + "/first-id.mp4", Parent: []string{"u", "third"}, ID: "first-id"}, wantRequests: 3},
+	}
+
+	var hash string
+	for _, download := range downloads {
+		completed, err := d.Download(context.Background(), download.media)
+		if err != nil {
+			t.Fatalf("Download(%v) error = %v", download.media, err)
+		}
+
+		if requests != download.wantRequests {
+			t.Errorf("Download(%v) made %d requests, want %d", download.media, requests, download.wantRequests)
+		}
+
+		if hash != "" && completed.SHA256 != hash {
+			t.Errorf("Download(%v) sha256 = %v, want %v", download.media, completed.SHA256, hash)
+		}
+
+		hash = completed.SHA256
+		if _, err := backend.Stat(context.Background(), completed.Path); err != nil {
+			t.Errorf("Stat(%s) error = %v", completed.Path, err)
+		}
+	}
+
+	blobs, _ := backend.List(context.Background(), "blobs/")
+	if len(blobs) != 1 || blobs[0].Key != dedup.BlobKey(hash) {
+		t.Errorf("blobs = %v, want a single blob for %s", blobs, hash)
+	}
+
+	if _, err := index.ByHash(context.Background(), hash); err != nil {
+		t.Errorf("ByHash() error = %v", err)
+	}
+}
+
+func TestDownloader_Download_Stream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/somevideo/HLSPlaylist.m3u8":
+			_, _ = w.Write([]byte("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360\nHLS_360.m3u8\n"))
+		case "/somevideo/HLS_360.m3u8":
+			_, _ = w.Write([]byte("#EXTM3U\n#EXTINF:4.0,\nHLS_360_0.ts\n#EXTINF:4.0,\nHLS_360_1.ts\n#EXT-X-ENDLIST\n"))
+		case "/somevideo/HLS_360_0.ts", "/somevideo/HLS_360_1.ts":
+			_, _ = w.Write([]byte(strings.TrimPrefix(r.URL.Path, "/somevideo/")))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		media    media.Media
+		maxSize  int64
+		wantPath string
+		wantErr  error
+	}{
+		{
+			name:     "Should concatenate the segments into a file named after the media ID",
+			media:    media.Media{URL: server.URL + "/somevideo/HLSPlaylist.m3u8", Parent: []string{"u", "someone"}, ID: "reddit-somevideo"},
+			wantPath: "u/someone/reddit-somevideo.ts",
+		},
+		{
+			name:     "Should name streams without an ID after their directory",
+			media:    media.Media{URL: server.URL + "/somevideo/HLSPlaylist.m3u8", Parent: []string{"u", "someone"}},
+			wantPath: "u/someone/somevideo.ts",
+		},
+		{
+			name:    "Should reject streams larger than the limit",
+			media:   media.Media{URL: server.URL + "/somevideo/HLSPlaylist.m3u8", Parent: []string{"u", "someone"}},
+			maxSize: 10,
+			wantErr: ErrTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			d := New(server.Client(), storage.NewLocal(dir), &fakeEvents{}, Options{PartsDir: t.TempDir(), MaxSize: tt.maxSize}, logging.Discard())
+
+			completed, err := d.Download(context.Background(), tt.media)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Download() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			if completed.Path != tt.wantPath || completed.ContentType != "video/mp2t" {
+				t.Errorf("Download() = %+v, want %s", completed, tt.wantPath)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(tt.wantPath)))
+			if err != nil || string(content) != "HLS_360_0.tsHLS_360_1.ts" {
+				t.Errorf("stored content = %q, err %v", content, err)
+			}
+		})
+	}
+}
+
+func TestSetup_WithoutClientID(t *testing.T) {
+	errStarted := errors.New("started")
+	cfg := config.Default()
+	cfg.Broker = config.BrokerMemory
+	cfg.Storage.Backend = config.StorageMemory
+
+	cmd := app.Command{
+		Name:      "downloader",
+		Required:  Required,
+		InProcess: true,
+		Setup: func(ctx context.Context, a *app.App) error {
+			if err := Setup(ctx, a); err != nil {
+				return err
+			}
+
+			return errStarted
+		},
+	}
+
+	if err := app.Run(cmd, cfg, nil); !errors.Is(err, errStarted) {
+		t.Errorf("Run() error = %v, wantErr %v", err, errStarted)
+	}
+}
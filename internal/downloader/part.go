@@ -0,0 +1,144 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type (
+	part struct {
+		path string
+		meta partMeta
+		hash string
+	}
+
+	partMeta struct {
+		URL          string `json:"url"`
+		ETag         string `json:"etag,omitempty"`
+		LastModified string `json:"last_modified,omitempty"`
+		ContentType  string `json:"content_type,omitempty"`
+	}
+)
+
+func newPart(dir, key string) *part {
+	sum := sha256.Sum256([]byte(key))
+	return &part{
+		path: filepath.Join(dir, hex.EncodeToString(sum[:16])+".part"),
+	}
+}
+
+func (p *part) resume(rawURL string) int64 {
+	content, err := os.ReadFile(p.metaPath())
+	if err != nil || json.Unmarshal(content, &p.meta) != nil || p.meta.URL != rawURL || p.validator() == "" {
+		p.remove()
+		p.meta = partMeta{URL: rawURL}
+		return 0
+	}
+
+	stat, err := os.Stat(p.path)
+	if err != nil {
+		return 0
+	}
+
+	return stat.Size()
+}
+
+func (p *part) validator() string {
+	if p.meta.ETag != "" && !strings.HasPrefix(p.meta.ETag, "W/") {
+		return p.meta.ETag
+	}
+
+	return p.meta.LastModified
+}
+
+func (p *part) save() error {
+	if err := os.MkdirAll(filepath.Dir(p.path), 0o755); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(p.meta)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(p.metaPath(), content, 0o644)
+}
+
+func (p *part) write(r io.Reader, offset int64) (int64, error) {
+	flags := os.O_CREATE | os.O_RDWR
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(p.path, flags, 0o644)
+	if err != nil {
+		return 0, err
+	}
+
+	hasher := sha256.New()
+	if _, err := io.CopyN(hasher, file, offset); err != nil {
+		_ = file.Close()
+		return 0, err
+	}
+
+	n, err := io.Copy(io.MultiWriter(file, hasher), r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	p.hash = hex.EncodeToString(hasher.Sum(nil))
+	return n, err
+}
+
+func (p *part) open() (*os.File, error) {
+	return os.Open(p.path)
+}
+
+func (p *part) remove() {
+	_ = os.Remove(p.path)
+	_ = os.Remove(p.metaPath())
+}
+
+func (p *part) metaPath() string {
+	return p.path + ".json"
+}
+
+func contentRange(header string) (int64, int64, bool) {
+	spec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+
+	byteRange, size, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+
+	total := int64(-1)
+	if size != "*" {
+		parsed, err := strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+
+		total = parsed
+	}
+
+	if byteRange == "*" {
+		return -1, total, true
+	}
+
+	first, _, found := strings.Cut(byteRange, "-")
+	start, err := strconv.ParseInt(first, 10, 64)
+	if !found || err != nil {
+		return 0, 0, false
+	}
+
+	return start, total, true
+}
@@ -0,0 +1,36 @@
+package router
+
+import (
+	"github.com/alancesar/imgur-fetcher/api"
+	"github.com/alancesar/imgur-fetcher/internal/controller"
+	"github.com/alancesar/imgur-fetcher/pkg/apikey"
+	"github.com/alancesar/imgur-fetcher/pkg/health"
+	"github.com/alancesar/imgur-fetcher/pkg/httpcache"
+	"github.com/alancesar/imgur-fetcher/pkg/imgur"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"github.com/alancesar/imgur-fetcher/pkg/metrics"
+	"github.com/alancesar/imgur-fetcher/pkg/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"log/slog"
+)
+
+func New(c *controller.Controller, keys *apikey.Store, checks *health.Registry, m *metrics.Metrics, cache *httpcache.Cache, logger *slog.Logger) chi.Router {
+	mux := chi.NewMux()
+	mux.Use(middleware.RequestID, tracing.Middleware, logging.Middleware(logger), m.Middleware)
+	mux.Get("/healthz", checks.Liveness)
+	mux.Get("/readyz", checks.Readiness)
+
+	mux.Group(func(mux chi.Router) {
+		mux.Use(middleware.SetHeader("Content-Type", "application/json"))
+		mux.Get("/openapi.json", api.Handler)
+		mux.With(apikey.Middleware(keys, apikey.ScopeResolve)).Post("/", c.GetMediaByURL)
+		mux.With(apikey.Middleware(keys, apikey.ScopeResolve)).Get("/search", c.Search)
+		mux.With(apikey.Middleware(keys, apikey.ScopePublish)).Post("/publish", c.PublishMedia)
+		mux.With(apikey.Middleware(keys, apikey.ScopeAdmin)).Get("/admin/usage", apikey.UsageHandler(keys))
+		mux.With(apikey.Middleware(keys, apikey.ScopeAdmin)).Get("/admin/cache", httpcache.StatsHandler(cache))
+		mux.With(apikey.Middleware(keys, apikey.ScopeAdmin)).Delete("/admin/cache", httpcache.PurgeHandler(cache, imgur.CacheKeys))
+	})
+
+	return mux
+}
@@ -0,0 +1,280 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/api"
+	"github.com/alancesar/imgur-fetcher/internal/controller"
+	"github.com/alancesar/imgur-fetcher/pkg/apikey"
+	"github.com/alancesar/imgur-fetcher/pkg/client"
+	"github.com/alancesar/imgur-fetcher/pkg/health"
+	"github.com/alancesar/imgur-fetcher/pkg/httpcache"
+	"github.com/alancesar/imgur-fetcher/pkg/imgur"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"github.com/alancesar/imgur-fetcher/pkg/media"
+	"github.com/alancesar/imgur-fetcher/pkg/metrics"
+	"github.com/alancesar/imgur-fetcher/pkg/resolver"
+	"github.com/alancesar/imgur-fetcher/pkg/validation"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type (
+	spec struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+
+	fakeClient struct{}
+
+	fakeSearcher struct {
+		options imgur.GalleryOptions
+	}
+
+	fakePublisher struct {
+		sent  []any
+		limit int
+	}
+)
+
+func (fakeClient) Resolve(_ context.Context, _ string, _ resolver.Options) ([]resolver.Media, error) {
+	return []resolver.Media{
+		{Provider: "imgur", URL: "https://i.imgur.com/some-image.jpg", Type: "image/jpeg"},
+	}, nil
+}
+
+func (s *fakeSearcher) SearchItems(_ context.Context, _ imgur.SearchQuery, options imgur.GalleryOptions, _ int) ([]imgur.GalleryItem, error) {
+	s.options = options
+	return []imgur.GalleryItem{
+		{ID: "some-album", Link: "https://imgur.com/a/some-album", IsAlbum: true, ImagesCount: 1},
+		{ID: "AbC123", Link: "https://i.imgur.com/AbC123.jpg", Type: "image/jpeg"},
+	}, nil
+}
+
+func (*fakeSearcher) GalleryItemMedia(_ context.Context, item imgur.GalleryItem) ([]imgur.Media, error) {
+	if item.IsAlbum {
+		return []imgur.Media{{ID: "some-image", Link: "https://i.imgur.com/some-image.jpg", Type: "image/jpeg"}}, nil
+	}
+
+	return []imgur.Media{item.Media()}, nil
+}
+
+func (p *fakePublisher) Send(_ context.Context, v any) error {
+	if p.limit > 0 && len(p.sent) >= p.limit {
+		return errors.New("broker unavailable")
+	}
+
+	p.sent = append(p.sent, v)
+	return nil
+}
+
+func TestRoutesMatchSpec(t *testing.T) {
+	s := loadSpec(t)
+
+	var want []string
+	for path, operations := range s.Paths {
+		for method := range operations {
+			want = append(want, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	var got []string
+	keys, _ := apikey.NewStore()
+	if err := chi.Walk(New(controller.New(nil, nil, nil, nil, resolver.Policy{}, 2, logging.Discard()), keys, health.New(), metrics.New(), nil, logging.Discard()), func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		got = append(got, method+" "+route)
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+
+	sort.Strings(want)
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("routes = %v, spec = %v", got, want)
+	}
+}
+
+func TestSchemasMatchTypes(t *testing.T) {
+	s := loadSpec(t)
+
+	tests := []struct {
+		schema string
+		value  any
+	}{
+		{schema: "ResolveRequest", value: client.ResolveRequest{}},
+		{schema: "PublishRequest", value: controller.PublishRequest{}},
+		{schema: "PublishRequest", value: client.PublishRequest{}},
+		{schema: "Response", value: controller.Response{}},
+		{schema: "Response", value: client.Response{}},
+		{schema: "SearchResponse", value: controller.SearchResponse{}},
+		{schema: "SearchResponse", value: client.SearchResponse{}},
+		{schema: "ResolvedMedia", value: resolver.Media{}},
+		{schema: "ResolvedMedia", value: client.ResolvedMedia{}},
+		{schema: "Variant", value: resolver.Variant{}},
+		{schema: "Variant", value: client.Variant{}},
+		{schema: "ErrorResponse", value: controller.ErrorResponse{}},
+		{schema: "ErrorResponse", value: client.ErrorResponse{}},
+		{schema: "FieldError", value: validation.FieldError{}},
+		{schema: "FieldError", value: client.FieldError{}},
+		{schema: "Usage", value: apikey.Usage{}},
+		{schema: "Usage", value: client.Usage{}},
+		{schema: "Health", value: health.Response{}},
+		{schema: "CacheStats", value: httpcache.Stats{}},
+		{schema: "CacheStats", value: client.CacheStats{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schema+"/"+reflect.TypeOf(tt.value).String(), func(t *testing.T) {
+			schema, ok := s.Components.Schemas[tt.schema]
+			if !ok {
+				t.Fatalf("schema %s not found in spec", tt.schema)
+			}
+
+			var want []string
+			for property := range schema.Properties {
+				want = append(want, property)
+			}
+
+			got := jsonFields(reflect.TypeOf(tt.value))
+			sort.Strings(want)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("fields = %v, spec = %v", got, want)
+			}
+		})
+	}
+}
+
+func TestClientAgainstRouter(t *testing.T) {
+	keys, _ := apikey.NewStore(apikey.Key{
+		ID:     "some-id",
+		Key:    "some-key",
+		Scopes: []apikey.Scope{apikey.ScopeResolve, apikey.ScopePublish},
+	})
+
+	publisher := &fakePublisher{}
+	headServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer headServer.Close()
+
+	searcher := &fakeSearcher{}
+	server := httptest.NewServer(New(controller.New(headServer.Client(), fakeClient{}, searcher, publisher, resolver.Policy{}, 2, logging.Discard()), keys, health.New(), metrics.New(), nil, logging.Discard()))
+	defer server.Close()
+
+	c := client.New(server.URL, "some-key", server.Client())
+	res, err := c.Resolve(context.Background(), client.ResolveRequest{URL: "https://imgur.com/AbC123"})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	if want := []string{"https://i.imgur.com/some-image.jpg"}; !reflect.DeepEqual(res.URLs, want) {
+		t.Errorf("Resolve() got = %v, want %v", res.URLs, want)
+	}
+
+	if _, err := c.Resolve(context.Background(), client.ResolveRequest{URL: "https://imgur.com/t/funny", MaxPages: 3}); err != nil {
+		t.Errorf("Resolve() error = %v, want max pages lowered to the limit", err)
+	}
+
+	found, err := c.Search(context.Background(), client.SearchRequest{Q: "cats", Enqueue: true, Parent: []string{"cats"}, Variant: "original", MaxSize: 1024})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+
+	var urls []string
+	for _, m := range found.Media {
+		urls = append(urls, m.URL)
+	}
+
+	if want := []string{"https://i.imgur.com/some-image.jpg", "https://i.imgur.com/AbC123.jpg"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("Search() got = %v, want %v", urls, want)
+	}
+
+	if found.Enqueued != 2 || len(publisher.sent) != 2 {
+		t.Fatalf("Search() enqueued = %d, sent = %v", found.Enqueued, publisher.sent)
+	}
+
+	if post, ok := publisher.sent[0].(media.Post); !ok || !reflect.DeepEqual(post.Parent, []string{"cats"}) || post.Variant != "original" || post.MaxSize != 1024 {
+		t.Errorf("Search() sent = %+v, want a post carrying the parent, variant and max size", publisher.sent[0])
+	}
+
+	if _, err := c.Search(context.Background(), client.SearchRequest{Q: "cats", MaxPages: 3}); err != nil || searcher.options.MaxPages != 2 {
+		t.Errorf("Search() error = %v, max pages = %d, want max pages lowered to 2", err, searcher.options.MaxPages)
+	}
+
+	_, err = c.Search(context.Background(), client.SearchRequest{Q: "cats", Type: "bmp"})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || len(apiErr.Details) != 1 || apiErr.Details[0].Field != "q_type" {
+		t.Errorf("Search() error = %v, want a bad q_type", err)
+	}
+
+	err = c.Publish(context.Background(), client.PublishRequest{URL: "https://imgur.com/AbC123", Parent: []string{".."}})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || len(apiErr.Details) != 1 {
+		t.Errorf("Publish() error = %v, want a bad request with details", err)
+	}
+
+	err = c.Publish(context.Background(), client.PublishRequest{URL: headServer.URL + "/t/funny", Parent: []string{"funny"}, Sort: "top", MaxPages: 3})
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	want := media.Post{URL: headServer.URL + "/t/funny", Parent: []string{"funny"}, Sort: imgur.SortTop, Window: imgur.WindowWeek, MaxPages: 2}
+	if got := publisher.sent[len(publisher.sent)-1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Publish() sent = %+v, want %+v", got, want)
+	}
+
+	_, err = c.Usage(context.Background())
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Usage() error = %v, want forbidden", err)
+	}
+}
+
+func TestClientAgainstRouter_PartialEnqueue(t *testing.T) {
+	keys, _ := apikey.NewStore(apikey.Key{
+		ID:     "some-id",
+		Key:    "some-key",
+		Scopes: []apikey.Scope{apikey.ScopeResolve, apikey.ScopePublish},
+	})
+
+	publisher := &fakePublisher{limit: 1}
+	server := httptest.NewServer(New(controller.New(nil, fakeClient{}, &fakeSearcher{}, publisher, resolver.Policy{}, 2, logging.Discard()), keys, health.New(), metrics.New(), nil, logging.Discard()))
+	defer server.Close()
+
+	_, err := client.New(server.URL, "some-key", server.Client()).Search(context.Background(), client.SearchRequest{Q: "cats", Enqueue: true})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError || apiErr.Enqueued != 1 {
+		t.Errorf("Search() error = %+v, want a server error reporting 1 enqueued hit", err)
+	}
+}
+
+func loadSpec(t *testing.T) spec {
+	t.Helper()
+
+	var s spec
+	if err := json.Unmarshal(api.Spec, &s); err != nil {
+		t.Fatalf("failed to parse spec: %v", err)
+	}
+
+	return s
+}
+
+func jsonFields(t reflect.Type) []string {
+	fields := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}
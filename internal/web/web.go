@@ -0,0 +1,79 @@
+package web
+
+import (
+	"context"
+	"github.com/alancesar/imgur-fetcher/internal/app"
+	"github.com/alancesar/imgur-fetcher/internal/controller"
+	"github.com/alancesar/imgur-fetcher/internal/router"
+	"github.com/alancesar/imgur-fetcher/pkg/apikey"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+var (
+	Required = []string{"imgur.client_id", "http.port", "http.api_keys_file", "admin.port"}
+)
+
+func Setup(_ context.Context, a *app.App) error {
+	cfg := a.Config
+	publisher, err := a.Publisher(cfg.RabbitMQ.FetcherExchange, cfg.RabbitMQ.FetcherKey)
+	if err != nil {
+		return err
+	}
+
+	keys, err := apikey.NewFileStore(cfg.HTTP.APIKeysFile)
+	if err != nil {
+		return err
+	}
+
+	a.Lifecycle.Go("api keys watcher", func(ctx context.Context) error {
+		keys.Watch(ctx, cfg.HTTP.APIKeysReload, func(err error) {
+			a.Logger.Error("failed to reload api keys", logging.KeyError, err)
+		})
+		return nil
+	})
+
+	a.Lifecycle.Go("api keys reloader", func(ctx context.Context) error {
+		hangup := make(chan os.Signal, 1)
+		signal.Notify(hangup, syscall.SIGHUP)
+		defer signal.Stop(hangup)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-hangup:
+				if err := keys.Reload(); err != nil {
+					a.Logger.Error("failed to reload api keys", logging.KeyError, err)
+				}
+			}
+		}
+	})
+
+	registry, err := a.Resolver()
+	if err != nil {
+		return err
+	}
+
+	imgurClient, err := a.ImgurClient()
+	if err != nil {
+		return err
+	}
+
+	cache, err := a.Cache()
+	if err != nil {
+		return err
+	}
+
+	policy, err := a.VariantPolicy()
+	if err != nil {
+		return err
+	}
+
+	imgurController := controller.New(a.PolicyClient(), registry, imgurClient, publisher, policy, cfg.Imgur.MaxPages, a.Logger)
+	a.ServeAdmin()
+	a.Serve("http server", cfg.HTTP.Port, router.New(imgurController, keys, a.Health, a.Metrics, cache, a.Logger))
+	return nil
+}
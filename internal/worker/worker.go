@@ -0,0 +1,285 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/internal/app"
+	"github.com/alancesar/imgur-fetcher/pkg/dedup"
+	"github.com/alancesar/imgur-fetcher/pkg/idempotency"
+	"github.com/alancesar/imgur-fetcher/pkg/imgur"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"github.com/alancesar/imgur-fetcher/pkg/media"
+	"github.com/alancesar/imgur-fetcher/pkg/metrics"
+	"github.com/alancesar/imgur-fetcher/pkg/pubsub"
+	"github.com/alancesar/imgur-fetcher/pkg/resolver"
+	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"github.com/alancesar/imgur-fetcher/pkg/urlpolicy"
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+var (
+	Required = []string{"imgur.client_id", "admin.port"}
+)
+
+type (
+	Client interface {
+		Resolve(ctx context.Context, rawURL string, options resolver.Options) ([]resolver.Media, error)
+	}
+
+	Publisher interface {
+		Publish(ctx context.Context, m media.Media) error
+	}
+
+	Worker struct {
+		client    Client
+		publisher Publisher
+		index     dedup.Index
+		store     idempotency.Store
+		policy    resolver.Policy
+		maxPages  int
+		metrics   *metrics.Metrics
+		logger    *slog.Logger
+	}
+)
+
+func New(client Client, publisher Publisher, index dedup.Index, store idempotency.Store, policy resolver.Policy, maxPages int, m *metrics.Metrics, logger *slog.Logger) *Worker {
+	return &Worker{
+		client:    client,
+		publisher: publisher,
+		index:     index,
+		store:     store,
+		policy:    policy,
+		maxPages:  maxPages,
+		metrics:   m,
+		logger:    logger,
+	}
+}
+
+func Setup(_ context.Context, a *app.App) error {
+	cfg := a.Config
+	publisher, err := a.Publisher(cfg.RabbitMQ.DownloadsExchange, cfg.RabbitMQ.DownloadsKey)
+	if err != nil {
+		return err
+	}
+
+	index, err := a.Index()
+	if err != nil {
+		return err
+	}
+
+	store, err := a.Idempotency()
+	if err != nil {
+		return err
+	}
+
+	registry, err := a.Resolver()
+	if err != nil {
+		return err
+	}
+
+	policy, err := a.VariantPolicy()
+	if err != nil {
+		return err
+	}
+
+	w := New(registry, publisher, index, store, policy, cfg.Imgur.MaxPages, a.Metrics, a.Logger)
+	if err := a.Subscribe(cfg.RabbitMQ.FetcherQueue, w.HandleMessage); err != nil {
+		return err
+	}
+
+	a.ServeAdmin()
+	return nil
+}
+
+func (w Worker) HandleMessage(ctx context.Context, body []byte) error {
+	var p media.Post
+	if err := json.Unmarshal(body, &p); err != nil {
+		return fmt.Errorf("%w: failed to unmarshal message: %v", app.ErrPermanent, err)
+	}
+
+	ctx = logging.With(ctx, logging.KeyURL, p.URL, "author", p.Author)
+	policy := w.policy
+	if p.Variant != "" || p.MaxSize != 0 {
+		variant := p.Variant
+		if variant == "" {
+			variant = w.policy.Name
+		}
+
+		parsed, err := resolver.ParsePolicy(variant, p.MaxSize)
+		if err != nil {
+			return fmt.Errorf("%w: %w", app.ErrPermanent, err)
+		}
+
+		policy = parsed
+	}
+
+	gallery, err := imgur.ParseGalleryOptions(p.Sort, p.Window, p.MaxPages, w.maxPages)
+	if err != nil {
+		return fmt.Errorf("%w: %w", app.ErrPermanent, err)
+	}
+
+	return w.Handle(ctx, p.Media(), policy, resolver.Options{
+		Sort:     gallery.Sort,
+		Window:   gallery.Window,
+		MaxPages: gallery.MaxPages,
+	})
+}
+
+func (w Worker) Handle(ctx context.Context, req media.Media, policy resolver.Policy, options resolver.Options) error {
+	key := w.key(ctx, req)
+	if w.seen(ctx, key) {
+		w.logger.DebugContext(ctx, "message already forwarded, skipping", "idempotency_key", key)
+		return nil
+	}
+
+	if err := w.handle(ctx, req, policy, options); err != nil {
+		return err
+	}
+
+	w.mark(ctx, key)
+	return nil
+}
+
+func (w Worker) handle(ctx context.Context, req media.Media, policy resolver.Policy, options resolver.Options) error {
+	if entry, ok := w.known(ctx, req.URL); ok && policy == w.policy && policy.Name != resolver.PolicyAll {
+		w.logger.DebugContext(ctx, "imgur id already downloaded, skipping api call", logging.KeyImgurID, entry.ID)
+		return w.forward(ctx, media.Media{
+			URL:    entry.URL,
+			Parent: req.Parent,
+			Size:   entry.Size,
+			ID:     entry.ID,
+		})
+	}
+
+	mediaList, err := w.client.Resolve(ctx, req.URL, options)
+	if err != nil {
+		if errors.Is(err, status.ErrNotFound) {
+			w.logger.WarnContext(ctx, "media not found, skipping", logging.KeyError, err)
+			return nil
+		} else if errors.Is(err, resolver.ErrUnsupported) || errors.Is(err, urlpolicy.ErrRejected) {
+			return fmt.Errorf("%w: %w", app.ErrPermanent, err)
+		}
+
+		return fmt.Errorf("failed to retrieve media: %w", err)
+	}
+
+	mediaList = policy.Apply(mediaList)
+	w.metrics.ObserveAlbumItems(len(mediaList))
+
+	parent := req.Parent
+	if request, ok := imgurRequest(req.URL); ok {
+		if listing := request.Listing(); listing != nil {
+			parent = listing
+			w.logger.InfoContext(ctx, "fanning out imgur listing", "parent", strings.Join(parent, "/"), "items", len(mediaList))
+		}
+	}
+
+	for _, m := range mediaList {
+		if err := w.forward(ctx, media.Media{
+			URL:    m.URL,
+			Parent: parent,
+			Size:   m.Size,
+			ID:     m.ID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w Worker) forward(ctx context.Context, m media.Media) error {
+	key := "media:" + m.MessageID()
+	if w.seen(ctx, key) {
+		w.logger.DebugContext(ctx, "media already forwarded, skipping", logging.KeyImgurID, m.ID)
+		return nil
+	}
+
+	if err := w.publisher.Publish(ctx, m); err != nil {
+		return fmt.Errorf("failed to publish media: %w", err)
+	}
+
+	w.mark(ctx, key)
+	return nil
+}
+
+func (w Worker) key(ctx context.Context, req media.Media) string {
+	request, ok := imgurRequest(req.URL)
+	if ok && request.Listing() != nil {
+		return ""
+	}
+
+	if id := pubsub.MessageID(ctx); id != "" {
+		return "message:" + id
+	}
+
+	if ok {
+		return "imgur:" + request.ID + ":" + strings.Join(req.Parent, "/")
+	}
+
+	return "url:" + req.URL + ":" + strings.Join(req.Parent, "/")
+}
+
+func (w Worker) seen(ctx context.Context, key string) bool {
+	if w.store == nil || key == "" {
+		return false
+	}
+
+	seen, err := w.store.Seen(ctx, key)
+	if err != nil {
+		w.logger.WarnContext(ctx, "failed to look up the idempotency store", logging.KeyError, err)
+		return false
+	}
+
+	return seen
+}
+
+func (w Worker) mark(ctx context.Context, key string) {
+	if w.store == nil || key == "" {
+		return
+	}
+
+	if err := w.store.Mark(ctx, key); err != nil {
+		w.logger.WarnContext(ctx, "failed to record the idempotency key", logging.KeyError, err)
+	}
+}
+
+func (w Worker) known(ctx context.Context, rawURL string) (dedup.Entry, bool) {
+	if w.index == nil || strings.Contains(rawURL, "/gallery/") {
+		return dedup.Entry{}, false
+	}
+
+	request, ok := imgurRequest(rawURL)
+	if !ok || request.IsAlbum || request.Listing() != nil {
+		return dedup.Entry{}, false
+	}
+
+	entry, err := w.index.ByID(ctx, request.ID)
+	if err != nil {
+		if !errors.Is(err, dedup.ErrNotFound) {
+			w.logger.WarnContext(ctx, "failed to look up the dedup index", logging.KeyError, err)
+		}
+
+		return dedup.Entry{}, false
+	}
+
+	return entry, true
+}
+
+func imgurRequest(rawURL string) (imgur.Request, bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || !resolver.MatchHost(parsed.Hostname(), imgur.Hosts...) {
+		return imgur.Request{}, false
+	}
+
+	request, err := imgur.ParseURL(rawURL)
+	if err != nil || (request.Listing() == nil && (request.ID == "" || request.ID == "." || request.ID == "/")) {
+		return imgur.Request{}, false
+	}
+
+	return request, true
+}
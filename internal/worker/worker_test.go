@@ -0,0 +1,319 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/internal/app"
+	"github.com/alancesar/imgur-fetcher/pkg/dedup"
+	"github.com/alancesar/imgur-fetcher/pkg/idempotency"
+	"github.com/alancesar/imgur-fetcher/pkg/imgur"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"github.com/alancesar/imgur-fetcher/pkg/media"
+	"github.com/alancesar/imgur-fetcher/pkg/metrics"
+	"github.com/alancesar/imgur-fetcher/pkg/pubsub"
+	"github.com/alancesar/imgur-fetcher/pkg/resolver"
+	"github.com/alancesar/imgur-fetcher/pkg/urlpolicy"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type (
+	fakeClient struct {
+		calls   int
+		options resolver.Options
+		media   []resolver.Media
+		err     error
+	}
+
+	fakePublisher struct {
+		published []media.Media
+	}
+)
+
+func (c *fakeClient) Resolve(_ context.Context, _ string, options resolver.Options) ([]resolver.Media, error) {
+	c.calls++
+	c.options = options
+	return c.media, c.err
+}
+
+func (p *fakePublisher) Publish(_ context.Context, m media.Media) error {
+	p.published = append(p.published, m)
+	return nil
+}
+
+func TestWorker_Handle(t *testing.T) {
+	index := dedup.NewMemoryIndex()
+	if err := index.Put(context.Background(), dedup.Entry{
+		ID:   "known-id",
+		Hash: "abcdef",
+		URL:  "https://i.imgur.com/known-id.jpg",
+		Size: 42,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		url       string
+		wantCalls int
+		want      media.Media
+	}{
+		{
+			name:      "Should skip the API call for known Imgur IDs",
+			url:       "https://imgur.com/known-id",
+			wantCalls: 0,
+			want:      media.Media{URL: "https://i.imgur.com/known-id.jpg", Parent: []string{"u", "someone"}, Size: 42, ID: "known-id"},
+		},
+		{
+			name:      "Should resolve unknown Imgur IDs",
+			url:       "https://imgur.com/unknown-id",
+			wantCalls: 1,
+			want:      media.Media{URL: "https://i.imgur.com/unknown-id.mp4", Parent: []string{"u", "someone"}, Size: 7, ID: "unknown-id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{media: []resolver.Media{imgur.Media{ID: "unknown-id", Type: "image/gif", Link: "https://i.imgur.com/unknown-id.gif", MP4: "https://i.imgur.com/unknown-id.mp4", Size: 21, MP4Size: 7}.Resolved()}}
+			publisher := &fakePublisher{}
+			w := New(client, publisher, index, nil, resolver.Policy{Name: resolver.PolicyMP4}, 2, metrics.New(), logging.Discard())
+
+			if err := w.Handle(context.Background(), media.Media{URL: tt.url, Parent: []string{"u", "someone"}}, w.policy, resolver.Options{}); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			if client.calls != tt.wantCalls {
+				t.Errorf("Resolve() calls = %d, want %d", client.calls, tt.wantCalls)
+			}
+
+			if len(publisher.published) != 1 || !reflect.DeepEqual(publisher.published[0], tt.want) {
+				t.Errorf("Publish() = %+v, want %+v", publisher.published, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorker_Handle_Idempotency(t *testing.T) {
+	album := []resolver.Media{
+		{ID: "first-id", Provider: imgur.ProviderName, URL: "https://i.imgur.com/first-id.jpg"},
+		{ID: "second-id", Provider: imgur.ProviderName, URL: "https://i.imgur.com/second-id.jpg"},
+	}
+
+	tests := []struct {
+		name          string
+		first         context.Context
+		second        context.Context
+		secondParent  []string
+		wantCalls     int
+		wantPublished int
+	}{
+		{
+			name:          "Should skip messages already forwarded",
+			first:         context.Background(),
+			second:        context.Background(),
+			secondParent:  []string{"u", "someone"},
+			wantCalls:     1,
+			wantPublished: 2,
+		},
+		{
+			name:          "Should skip messages with a known message ID",
+			first:         pubsub.WithMessageID(context.Background(), "some-message"),
+			second:        pubsub.WithMessageID(context.Background(), "some-message"),
+			secondParent:  []string{"u", "someone"},
+			wantCalls:     1,
+			wantPublished: 2,
+		},
+		{
+			name:          "Should skip items already forwarded by another message",
+			first:         pubsub.WithMessageID(context.Background(), "some-message"),
+			second:        pubsub.WithMessageID(context.Background(), "another-message"),
+			secondParent:  []string{"u", "someone"},
+			wantCalls:     2,
+			wantPublished: 2,
+		},
+		{
+			name:          "Should forward the same album to another parent",
+			first:         context.Background(),
+			second:        context.Background(),
+			secondParent:  []string{"u", "someone-else"},
+			wantCalls:     2,
+			wantPublished: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{media: album}
+			publisher := &fakePublisher{}
+			w := New(client, publisher, nil, idempotency.NewMemory(10, time.Hour), resolver.Policy{Name: resolver.PolicyMP4}, 2, metrics.New(), logging.Discard())
+
+			if err := w.Handle(tt.first, media.Media{URL: "https://imgur.com/a/some-album", Parent: []string{"u", "someone"}}, w.policy, resolver.Options{}); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			if err := w.Handle(tt.second, media.Media{URL: "https://imgur.com/a/some-album", Parent: tt.secondParent}, w.policy, resolver.Options{}); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			if client.calls != tt.wantCalls {
+				t.Errorf("Resolve() calls = %d, want %d", client.calls, tt.wantCalls)
+			}
+
+			if len(publisher.published) != tt.wantPublished {
+				t.Errorf("Publish() = %d messages, want %d", len(publisher.published), tt.wantPublished)
+			}
+		})
+	}
+}
+
+func TestWorker_Handle_Permanent(t *testing.T) {
+	got := media.Post{Author: "someone", URL: "https://imgur.com/some-id"}.Media()
+	if err := (Worker{}).HandleMessage(context.Background(), []byte("{")); !errors.Is(err, app.ErrPermanent) {
+		t.Errorf("HandleMessage() error = %v, want a permanent failure", err)
+	}
+
+	w := New(&fakeClient{err: resolver.ErrUnsupported}, &fakePublisher{}, nil, nil, resolver.Policy{Name: resolver.PolicyMP4}, 2, metrics.New(), logging.Discard())
+	if err := w.Handle(context.Background(), got, w.policy, resolver.Options{}); !errors.Is(err, app.ErrPermanent) {
+		t.Errorf("Handle() error = %v, want a permanent failure for unsupported URLs", err)
+	}
+
+	w = New(&fakeClient{err: urlpolicy.ErrRejected}, &fakePublisher{}, nil, nil, resolver.Policy{Name: resolver.PolicyMP4}, 2, metrics.New(), logging.Discard())
+	if err := w.Handle(context.Background(), got, w.policy, resolver.Options{}); !errors.Is(err, app.ErrPermanent) {
+		t.Errorf("Handle() error = %v, want a permanent failure for URLs rejected by the policy", err)
+	}
+}
+
+func TestWorker_HandleMessage_Variant(t *testing.T) {
+	resolved := imgur.Media{ID: "some-id", Type: "image/gif", Link: "https://i.imgur.com/some-id.gif", MP4: "https://i.imgur.com/some-id.mp4", Size: 21, MP4Size: 7}.Resolved()
+
+	tests := []struct {
+		name    string
+		body    string
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "Should use the worker policy when the payload has none",
+			body: `{"author":"someone","url":"https://imgur.com/some-id"}`,
+			want: []string{"https://i.imgur.com/some-id.mp4"},
+		},
+		{
+			name: "Should keep the original when the payload asks for it",
+			body: `{"author":"someone","url":"https://imgur.com/some-id","variant":"original"}`,
+			want: []string{"https://i.imgur.com/some-id.gif"},
+		},
+		{
+			name: "Should forward every variant when the payload asks for all",
+			body: `{"author":"someone","url":"https://imgur.com/some-id","variant":"all"}`,
+			want: []string{"https://i.imgur.com/some-id.gif", "https://i.imgur.com/some-id.mp4"},
+		},
+		{
+			name: "Should drop media larger than the payload cap",
+			body: `{"author":"someone","url":"https://imgur.com/some-id","variant":"smallest","max_size":5}`,
+		},
+		{
+			name:    "Should reject unknown gallery sorts",
+			body:    `{"author":"someone","url":"https://imgur.com/t/funny","sort":"rising"}`,
+			wantErr: app.ErrPermanent,
+		},
+		{
+			name:    "Should reject unknown variant policies",
+			body:    `{"author":"someone","url":"https://imgur.com/some-id","variant":"bogus"}`,
+			wantErr: app.ErrPermanent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &fakePublisher{}
+			w := New(&fakeClient{media: []resolver.Media{resolved}}, publisher, nil, nil, resolver.Policy{Name: resolver.PolicyMP4}, 2, metrics.New(), logging.Discard())
+
+			if err := w.HandleMessage(context.Background(), []byte(tt.body)); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("HandleMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var got []string
+			for _, m := range publisher.published {
+				got = append(got, m.URL)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Publish() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorker_HandleMessage_GalleryOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want resolver.Options
+	}{
+		{
+			name: "Should default the gallery options",
+			body: `{"author":"someone","url":"https://imgur.com/t/funny"}`,
+			want: resolver.Options{Sort: imgur.SortViral, Window: imgur.WindowWeek},
+		},
+		{
+			name: "Should pass the payload gallery options to the resolver",
+			body: `{"author":"someone","url":"https://imgur.com/t/funny","sort":"top","window":"day","max_pages":1}`,
+			want: resolver.Options{Sort: imgur.SortTop, Window: imgur.WindowDay, MaxPages: 1},
+		},
+		{
+			name: "Should lower max pages to the worker limit",
+			body: `{"author":"someone","url":"https://imgur.com/t/funny","max_pages":5}`,
+			want: resolver.Options{Sort: imgur.SortViral, Window: imgur.WindowWeek, MaxPages: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{}
+			w := New(client, &fakePublisher{}, nil, nil, resolver.Policy{Name: resolver.PolicyMP4}, 2, metrics.New(), logging.Discard())
+
+			if err := w.HandleMessage(context.Background(), []byte(tt.body)); err != nil {
+				t.Fatalf("HandleMessage() error = %v", err)
+			}
+
+			if client.options != tt.want {
+				t.Errorf("Resolve() options = %+v, want %+v", client.options, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorker_Handle_Listing(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		wantParent []string
+	}{
+		{name: "Should set the parent of user items to the user", url: "https://imgur.com/user/someone-else", wantParent: []string{"u", "someone-else"}},
+		{name: "Should set the parent of tag items to the tag", url: "https://imgur.com/t/funny", wantParent: []string{"t", "funny"}},
+		{name: "Should set the parent of subreddit items to the subreddit", url: "https://imgur.com/r/aww", wantParent: []string{"r", "aww"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{media: []resolver.Media{
+				{ID: "first-id", Provider: imgur.ProviderName, URL: "https://i.imgur.com/first-id.jpg"},
+				{ID: "second-id", Provider: imgur.ProviderName, URL: "https://i.imgur.com/second-id.jpg"},
+			}}
+			publisher := &fakePublisher{}
+			w := New(client, publisher, dedup.NewMemoryIndex(), idempotency.NewMemory(10, time.Hour), resolver.Policy{Name: resolver.PolicyMP4}, 2, metrics.New(), logging.Discard())
+
+			ctx := pubsub.WithMessageID(context.Background(), "some-message-id")
+			for i := 0; i < 2; i++ {
+				if err := w.Handle(ctx, media.Media{URL: tt.url, Parent: []string{"u", "someone"}}, w.policy, resolver.Options{}); err != nil {
+					t.Fatalf("Handle() error = %v", err)
+				}
+			}
+
+			want := []media.Media{
+				{URL: "https://i.imgur.com/first-id.jpg", Parent: tt.wantParent, ID: "first-id"},
+				{URL: "https://i.imgur.com/second-id.jpg", Parent: tt.wantParent, ID: "second-id"},
+			}
+
+			if client.calls != 2 || !reflect.DeepEqual(publisher.published, want) {
+				t.Errorf("Publish() = %+v after %d calls, want %+v", publisher.published, client.calls, want)
+			}
+		})
+	}
+}
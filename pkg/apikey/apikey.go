@@ -0,0 +1,277 @@
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/time/rate"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	ScopeResolve Scope = "resolve"
+	ScopePublish Scope = "publish"
+	ScopeAdmin   Scope = "admin"
+)
+
+var (
+	ErrUnknownKey   = errors.New("unknown api key")
+	ErrMissingScope = errors.New("missing scope")
+	ErrRateLimited  = errors.New("rate limit exceeded")
+)
+
+type (
+	Scope string
+
+	RateLimit struct {
+		RequestsPerSecond float64 `json:"requests_per_second"`
+		Burst             int     `json:"burst"`
+	}
+
+	Key struct {
+		ID        string    `json:"id"`
+		Key       string    `json:"key"`
+		Scopes    []Scope   `json:"scopes"`
+		RateLimit RateLimit `json:"rate_limit"`
+	}
+
+	Usage struct {
+		ID       string    `json:"id"`
+		Scopes   []Scope   `json:"scopes"`
+		Allowed  uint64    `json:"allowed"`
+		Rejected uint64    `json:"rejected"`
+		LastUsed time.Time `json:"last_used,omitempty"`
+	}
+
+	Store struct {
+		path    string
+		mu      sync.RWMutex
+		keys    map[string]*entry
+		modTime time.Time
+	}
+
+	entry struct {
+		key      Key
+		limiter  *rate.Limiter
+		allowed  atomic.Uint64
+		rejected atomic.Uint64
+		lastUsed atomic.Int64
+	}
+)
+
+func NewStore(keys ...Key) (*Store, error) {
+	s := &Store{}
+	if err := s.replace(keys); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func NewFileStore(path string) (*Store, error) {
+	if path == "" {
+		return nil, errors.New("api keys file path is required")
+	}
+
+	s := &Store{
+		path: path,
+	}
+
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Store) Reload() error {
+	if s.path == "" {
+		return nil
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat api keys file: %w", err)
+	}
+
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read api keys file: %w", err)
+	}
+
+	var file struct {
+		Keys []Key `json:"keys"`
+	}
+
+	if err := json.Unmarshal(content, &file); err != nil {
+		return fmt.Errorf("failed to parse api keys file: %w", err)
+	}
+
+	if err := s.replace(file.Keys); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.modTime = info.ModTime()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Store) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	if s.path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(s.path)
+			if err != nil {
+				onError(err)
+				continue
+			}
+
+			s.mu.RLock()
+			changed := !info.ModTime().Equal(s.modTime)
+			s.mu.RUnlock()
+
+			if changed {
+				if err := s.Reload(); err != nil {
+					onError(err)
+				}
+			}
+		}
+	}
+}
+
+func (s *Store) Authorize(secret string, scope Scope) (Key, error) {
+	s.mu.RLock()
+	e, ok := s.keys[hash(secret)]
+	s.mu.RUnlock()
+
+	if !ok {
+		return Key{}, ErrUnknownKey
+	}
+
+	if !e.key.HasScope(scope) {
+		e.rejected.Add(1)
+		return e.key, fmt.Errorf("%w: %s", ErrMissingScope, scope)
+	}
+
+	e.lastUsed.Store(time.Now().UnixNano())
+	if !e.limiter.Allow() {
+		e.rejected.Add(1)
+		return e.key, ErrRateLimited
+	}
+
+	e.allowed.Add(1)
+	return e.key, nil
+}
+
+func (s *Store) Usage() []Usage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usage := make([]Usage, 0, len(s.keys))
+	for _, e := range s.keys {
+		u := Usage{
+			ID:       e.key.ID,
+			Scopes:   e.key.Scopes,
+			Allowed:  e.allowed.Load(),
+			Rejected: e.rejected.Load(),
+		}
+
+		if lastUsed := e.lastUsed.Load(); lastUsed > 0 {
+			u.LastUsed = time.Unix(0, lastUsed).UTC()
+		}
+
+		usage = append(usage, u)
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].ID < usage[j].ID
+	})
+
+	return usage
+}
+
+func (s *Store) replace(keys []Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := make(map[string]*entry, len(s.keys))
+	for _, e := range s.keys {
+		previous[e.key.ID] = e
+	}
+
+	entries := make(map[string]*entry, len(keys))
+	for _, k := range keys {
+		if k.ID == "" || k.Key == "" {
+			return errors.New("api key must have both id and key")
+		}
+
+		digest := hash(k.Key)
+		if _, exists := entries[digest]; exists {
+			return fmt.Errorf("duplicated api key: %s", k.ID)
+		}
+
+		e := &entry{
+			key:     k,
+			limiter: k.RateLimit.limiter(),
+		}
+
+		if old, ok := previous[k.ID]; ok {
+			e.allowed.Store(old.allowed.Load())
+			e.rejected.Store(old.rejected.Load())
+			e.lastUsed.Store(old.lastUsed.Load())
+			if old.key.RateLimit == k.RateLimit {
+				e.limiter = old.limiter
+			}
+		}
+
+		entries[digest] = e
+	}
+
+	s.keys = entries
+	return nil
+}
+
+func (k Key) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r RateLimit) limiter() *rate.Limiter {
+	if r.RequestsPerSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+
+	burst := r.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+
+	return rate.NewLimiter(rate.Limit(r.RequestsPerSecond), burst)
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
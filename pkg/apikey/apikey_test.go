@@ -0,0 +1,124 @@
+package apikey
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore_Authorize(t *testing.T) {
+	type args struct {
+		secret string
+		scope  Scope
+	}
+	tests := []struct {
+		name    string
+		keys    []Key
+		args    args
+		calls   int
+		wantErr error
+	}{
+		{
+			name: "Should authorize a known key with the required scope",
+			keys: []Key{
+				{ID: "some-id", Key: "some-key", Scopes: []Scope{ScopeResolve}},
+			},
+			args: args{
+				secret: "some-key",
+				scope:  ScopeResolve,
+			},
+			calls:   1,
+			wantErr: nil,
+		},
+		{
+			name: "Should reject an unknown key",
+			keys: []Key{
+				{ID: "some-id", Key: "some-key", Scopes: []Scope{ScopeResolve}},
+			},
+			args: args{
+				secret: "another-key",
+				scope:  ScopeResolve,
+			},
+			calls:   1,
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name: "Should reject a key without the required scope",
+			keys: []Key{
+				{ID: "some-id", Key: "some-key", Scopes: []Scope{ScopeResolve}},
+			},
+			args: args{
+				secret: "some-key",
+				scope:  ScopePublish,
+			},
+			calls:   1,
+			wantErr: ErrMissingScope,
+		},
+		{
+			name: "Should reject a key when its bucket is empty",
+			keys: []Key{
+				{ID: "some-id", Key: "some-key", Scopes: []Scope{ScopeResolve}, RateLimit: RateLimit{RequestsPerSecond: 0.001, Burst: 2}},
+			},
+			args: args{
+				secret: "some-key",
+				scope:  ScopeResolve,
+			},
+			calls:   3,
+			wantErr: ErrRateLimited,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewStore(tt.keys...)
+			if err != nil {
+				t.Fatalf("NewStore() error = %v", err)
+			}
+
+			for i := 0; i < tt.calls; i++ {
+				_, err = s.Authorize(tt.args.secret, tt.args.scope)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Authorize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStore_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	write(`{"keys": [{"id": "some-id", "key": "old-key", "scopes": ["resolve"]}]}`)
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	if _, err := s.Authorize("old-key", ScopeResolve); err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+
+	write(`{"keys": [{"id": "some-id", "key": "new-key", "scopes": ["resolve"]}]}`)
+	if err := s.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if _, err := s.Authorize("old-key", ScopeResolve); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Authorize() error = %v, wantErr %v", err, ErrUnknownKey)
+	}
+
+	if _, err := s.Authorize("new-key", ScopeResolve); err != nil {
+		t.Errorf("Authorize() error = %v, wantErr %v", err, nil)
+	}
+
+	usage := s.Usage()
+	if len(usage) != 1 || usage[0].Allowed != 2 {
+		t.Errorf("Usage() = %+v, want counters kept across reloads", usage)
+	}
+}
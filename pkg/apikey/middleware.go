@@ -0,0 +1,83 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	HeaderName = "X-API-Key"
+)
+
+type (
+	contextKey struct{}
+)
+
+func Middleware(store *Store, scope Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret := extract(r)
+			if secret == "" {
+				writeError(w, http.StatusUnauthorized, "missing api key")
+				return
+			}
+
+			key, err := store.Authorize(secret, scope)
+			switch {
+			case errors.Is(err, ErrUnknownKey):
+				writeError(w, http.StatusUnauthorized, err.Error())
+				return
+			case errors.Is(err, ErrMissingScope):
+				writeError(w, http.StatusForbidden, err.Error())
+				return
+			case errors.Is(err, ErrRateLimited):
+				w.Header().Set("Retry-After", "1")
+				writeError(w, http.StatusTooManyRequests, err.Error())
+				return
+			case err != nil:
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), contextKey{}, key)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func UsageHandler(store *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if err := json.NewEncoder(w).Encode(store.Usage()); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
+
+func FromContext(ctx context.Context) (Key, bool) {
+	key, ok := ctx.Value(contextKey{}).(Key)
+	return key, ok
+}
+
+func extract(r *http.Request) string {
+	if secret := r.Header.Get(HeaderName); secret != "" {
+		return secret
+	}
+
+	if token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+		return strings.TrimSpace(token)
+	}
+
+	return ""
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{
+		Error: message,
+	})
+}
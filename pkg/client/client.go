@@ -0,0 +1,279 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+)
+
+type (
+	Client struct {
+		baseURL    string
+		apiKey     string
+		httpClient *http.Client
+	}
+
+	ResolveRequest struct {
+		URL      string `json:"url"`
+		Variant  string `json:"variant,omitempty"`
+		MaxSize  int64  `json:"max_size,omitempty"`
+		Sort     string `json:"sort,omitempty"`
+		Window   string `json:"window,omitempty"`
+		MaxPages int    `json:"max_pages,omitempty"`
+	}
+
+	Response struct {
+		URLs []string `json:"urls"`
+	}
+
+	SearchRequest struct {
+		Q        string
+		All      string
+		Any      string
+		Exactly  string
+		Not      string
+		Type     string
+		SizePx   string
+		Sort     string
+		Window   string
+		Page     int
+		MaxPages int
+		Variant  string
+		MaxSize  int64
+		Enqueue  bool
+		Parent   []string
+	}
+
+	SearchResponse struct {
+		Media    []ResolvedMedia `json:"media"`
+		Enqueued int             `json:"enqueued"`
+	}
+
+	ResolvedMedia struct {
+		ID          string    `json:"id,omitempty"`
+		Provider    string    `json:"provider"`
+		Title       string    `json:"title,omitempty"`
+		Description string    `json:"description,omitempty"`
+		URL         string    `json:"url"`
+		Type        string    `json:"type,omitempty"`
+		Size        int64     `json:"size,omitempty"`
+		Variants    []Variant `json:"variants,omitempty"`
+	}
+
+	Variant struct {
+		URL       string `json:"url"`
+		Type      string `json:"type,omitempty"`
+		Size      int64  `json:"size,omitempty"`
+		Width     int    `json:"width,omitempty"`
+		Height    int    `json:"height,omitempty"`
+		Bandwidth int    `json:"bandwidth,omitempty"`
+	}
+
+	PublishRequest struct {
+		URL      string   `json:"url"`
+		Parent   []string `json:"parent"`
+		Sort     string   `json:"sort,omitempty"`
+		Window   string   `json:"window,omitempty"`
+		MaxPages int      `json:"max_pages,omitempty"`
+	}
+
+	FieldError struct {
+		Field   string `json:"field"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	ErrorResponse struct {
+		Error    string       `json:"error"`
+		Details  []FieldError `json:"details,omitempty"`
+		Enqueued int          `json:"enqueued,omitempty"`
+	}
+
+	Usage struct {
+		ID       string    `json:"id"`
+		Scopes   []string  `json:"scopes"`
+		Allowed  uint64    `json:"allowed"`
+		Rejected uint64    `json:"rejected"`
+		LastUsed time.Time `json:"last_used,omitempty"`
+	}
+
+	CacheStats struct {
+		Enabled     bool   `json:"enabled"`
+		Hits        uint64 `json:"hits"`
+		Misses      uint64 `json:"misses"`
+		Revalidated uint64 `json:"revalidated"`
+	}
+
+	Error struct {
+		StatusCode int
+		Message    string
+		Details    []FieldError
+		Enqueued   int
+	}
+)
+
+func New(baseURL, apiKey string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: httpClient,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("imgur-fetcher: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	return fmt.Sprintf("imgur-fetcher: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (c Client) Resolve(ctx context.Context, req ResolveRequest) (Response, error) {
+	var output Response
+	err := c.do(ctx, http.MethodPost, "/", req, http.StatusOK, &output)
+	return output, err
+}
+
+func (c Client) Search(ctx context.Context, req SearchRequest) (SearchResponse, error) {
+	var output SearchResponse
+	err := c.do(ctx, http.MethodGet, "/search?"+req.values().Encode(), nil, http.StatusOK, &output)
+	return output, err
+}
+
+func (c Client) Publish(ctx context.Context, req PublishRequest) error {
+	return c.do(ctx, http.MethodPost, "/publish", req, http.StatusAccepted, nil)
+}
+
+func (c Client) Usage(ctx context.Context) ([]Usage, error) {
+	var output []Usage
+	err := c.do(ctx, http.MethodGet, "/admin/usage", nil, http.StatusOK, &output)
+	return output, err
+}
+
+func (c Client) CacheStats(ctx context.Context) (CacheStats, error) {
+	var output CacheStats
+	err := c.do(ctx, http.MethodGet, "/admin/cache", nil, http.StatusOK, &output)
+	return output, err
+}
+
+func (c Client) PurgeCache(ctx context.Context, ids ...string) error {
+	path := "/admin/cache"
+	if len(ids) > 0 {
+		path += "?" + url.Values{"id": ids}.Encode()
+	}
+
+	return c.do(ctx, http.MethodDelete, path, nil, http.StatusNoContent, nil)
+}
+
+func (r SearchRequest) values() url.Values {
+	values := url.Values{}
+	for key, value := range map[string]string{
+		"q":         r.Q,
+		"q_all":     r.All,
+		"q_any":     r.Any,
+		"q_exactly": r.Exactly,
+		"q_not":     r.Not,
+		"q_type":    r.Type,
+		"q_size_px": r.SizePx,
+		"sort":      r.Sort,
+		"window":    r.Window,
+		"variant":   r.Variant,
+	} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+
+	if r.Page > 0 {
+		values.Set("page", strconv.Itoa(r.Page))
+	}
+
+	if r.MaxPages > 0 {
+		values.Set("max_pages", strconv.Itoa(r.MaxPages))
+	}
+
+	if r.MaxSize > 0 {
+		values.Set("max_size", strconv.FormatInt(r.MaxSize, 10))
+	}
+
+	if r.Enqueue {
+		values.Set("enqueue", "true")
+	}
+
+	for _, segment := range r.Parent {
+		values.Add("parent", segment)
+	}
+
+	return values
+}
+
+func (c Client) do(ctx context.Context, method, path string, input any, expected int, output any) error {
+	var body io.Reader
+	if input != nil {
+		content, err := json.Marshal(input)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+
+		body = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.apiKey != "" {
+		req.Header.Set(apiKeyHeader, c.apiKey)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode != expected {
+		apiErr := &Error{
+			StatusCode: res.StatusCode,
+		}
+
+		var errorResponse ErrorResponse
+		if err := json.NewDecoder(res.Body).Decode(&errorResponse); err == nil {
+			apiErr.Message = errorResponse.Error
+			apiErr.Details = errorResponse.Details
+			apiErr.Enqueued = errorResponse.Enqueued
+		}
+
+		return apiErr
+	}
+
+	if output == nil {
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(output)
+}
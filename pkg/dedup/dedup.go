@@ -0,0 +1,167 @@
+package dedup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/storage"
+	"regexp"
+	"sync"
+)
+
+var (
+	ErrNotFound     = errors.New("entry not found")
+	ErrInvalidEntry = errors.New("invalid index entry")
+
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+type (
+	Index interface {
+		ByID(ctx context.Context, id string) (Entry, error)
+		ByHash(ctx context.Context, hash string) (Entry, error)
+		Put(ctx context.Context, entry Entry) error
+	}
+
+	Entry struct {
+		ID          string `json:"id,omitempty"`
+		Hash        string `json:"hash"`
+		URL         string `json:"url,omitempty"`
+		Size        int64  `json:"size"`
+		ContentType string `json:"content_type,omitempty"`
+	}
+
+	MemoryIndex struct {
+		mu     sync.RWMutex
+		byID   map[string]Entry
+		byHash map[string]Entry
+	}
+
+	StorageIndex struct {
+		backend storage.Backend
+	}
+)
+
+func BlobKey(hash string) string {
+	if len(hash) < 2 {
+		return "blobs/" + hash
+	}
+
+	return "blobs/" + hash[:2] + "/" + hash
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		byID:   map[string]Entry{},
+		byHash: map[string]Entry{},
+	}
+}
+
+func (i *MemoryIndex) ByID(_ context.Context, id string) (Entry, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	entry, exists := i.byID[id]
+	if !exists {
+		return Entry{}, ErrNotFound
+	}
+
+	return entry, nil
+}
+
+func (i *MemoryIndex) ByHash(_ context.Context, hash string) (Entry, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	entry, exists := i.byHash[hash]
+	if !exists {
+		return Entry{}, ErrNotFound
+	}
+
+	return entry, nil
+}
+
+func (i *MemoryIndex) Put(_ context.Context, entry Entry) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.byHash[entry.Hash] = entry
+	if entry.ID != "" {
+		i.byID[entry.ID] = entry
+	}
+
+	return nil
+}
+
+func NewStorageIndex(backend storage.Backend) *StorageIndex {
+	return &StorageIndex{
+		backend: backend,
+	}
+}
+
+func (i StorageIndex) ByID(ctx context.Context, id string) (Entry, error) {
+	if !namePattern.MatchString(id) {
+		return Entry{}, ErrNotFound
+	}
+
+	return i.read(ctx, "index/id/"+id+".json")
+}
+
+func (i StorageIndex) ByHash(ctx context.Context, hash string) (Entry, error) {
+	if !namePattern.MatchString(hash) {
+		return Entry{}, ErrNotFound
+	}
+
+	return i.read(ctx, "index/sha256/"+hash+".json")
+}
+
+func (i StorageIndex) Put(ctx context.Context, entry Entry) error {
+	if !namePattern.MatchString(entry.Hash) || (entry.ID != "" && !namePattern.MatchString(entry.ID)) {
+		return fmt.Errorf("%w: %q, %q", ErrInvalidEntry, entry.ID, entry.Hash)
+	}
+
+	if err := i.write(ctx, "index/sha256/"+entry.Hash+".json", entry); err != nil {
+		return err
+	}
+
+	if entry.ID == "" {
+		return nil
+	}
+
+	return i.write(ctx, "index/id/"+entry.ID+".json", entry)
+}
+
+func (i StorageIndex) read(ctx context.Context, key string) (Entry, error) {
+	reader, _, err := i.backend.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotExist) || errors.Is(err, storage.ErrInvalidKey) {
+		return Entry{}, ErrNotFound
+	} else if err != nil {
+		return Entry{}, err
+	}
+
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	var entry Entry
+	if err := json.NewDecoder(reader).Decode(&entry); err != nil {
+		return Entry{}, fmt.Errorf("failed to decode index entry %s: %w", key, err)
+	}
+
+	return entry, nil
+}
+
+func (i StorageIndex) write(ctx context.Context, key string, entry Entry) error {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return i.backend.Put(ctx, key, bytes.NewReader(content), storage.Info{
+		Key:         key,
+		Size:        int64(len(content)),
+		ContentType: "application/json",
+	})
+}
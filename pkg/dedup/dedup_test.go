@@ -0,0 +1,53 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/pkg/storage"
+	"testing"
+)
+
+func TestIndexes(t *testing.T) {
+	indexes := map[string]Index{
+		"memory":  NewMemoryIndex(),
+		"storage": NewStorageIndex(storage.NewMemory()),
+	}
+	for name, index := range indexes {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			entry := Entry{ID: "some-id", Hash: "abcdef", URL: "https://i.imgur.com/some-id.jpg", Size: 42}
+			if _, err := index.ByID(ctx, entry.ID); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("ByID() error = %v, wantErr %v", err, ErrNotFound)
+			}
+
+			if err := index.Put(ctx, entry); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+
+			if got, err := index.ByID(ctx, entry.ID); err != nil || got != entry {
+				t.Errorf("ByID() = %+v, %v, want %+v", got, err, entry)
+			}
+
+			if got, err := index.ByHash(ctx, entry.Hash); err != nil || got != entry {
+				t.Errorf("ByHash() = %+v, %v, want %+v", got, err, entry)
+			}
+		})
+	}
+}
+
+func TestStorageIndex_InvalidNames(t *testing.T) {
+	index := NewStorageIndex(storage.NewMemory())
+	if err := index.Put(context.Background(), Entry{ID: "../escape", Hash: "abcdef"}); !errors.Is(err, ErrInvalidEntry) {
+		t.Errorf("Put() error = %v, wantErr %v", err, ErrInvalidEntry)
+	}
+
+	if _, err := index.ByID(context.Background(), "../escape"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ByID() error = %v, wantErr %v", err, ErrNotFound)
+	}
+}
+
+func TestBlobKey(t *testing.T) {
+	if got, want := BlobKey("abcdef"), "blobs/ab/abcdef"; got != want {
+		t.Errorf("BlobKey() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+
+	defaultTimeout = 2 * time.Second
+)
+
+var (
+	ErrClosed  = errors.New("closed")
+	ErrStopped = errors.New("stopped")
+)
+
+type (
+	Check func(ctx context.Context) error
+
+	Closer interface {
+		IsClosed() bool
+	}
+
+	Registry struct {
+		mu      sync.RWMutex
+		names   []string
+		checks  map[string]Check
+		timeout time.Duration
+	}
+
+	Response struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks,omitempty"`
+	}
+
+	Activity struct {
+		running atomic.Bool
+	}
+)
+
+func New() *Registry {
+	return &Registry{
+		checks:  map[string]Check{},
+		timeout: defaultTimeout,
+	}
+}
+
+func (r *Registry) Register(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.checks[name]; !exists {
+		r.names = append(r.names, name)
+	}
+
+	r.checks[name] = check
+}
+
+func (r *Registry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.checks[name]
+	return exists
+}
+
+func (r *Registry) Run(ctx context.Context) Response {
+	r.mu.RLock()
+	names := append([]string(nil), r.names...)
+	checks := make(map[string]Check, len(r.checks))
+	for name, check := range r.checks {
+		checks[name] = check
+	}
+	r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	response := Response{
+		Status: StatusOK,
+		Checks: make(map[string]string, len(names)),
+	}
+
+	for _, name := range names {
+		if err := checks[name](ctx); err != nil {
+			response.Status = StatusUnavailable
+			response.Checks[name] = err.Error()
+			continue
+		}
+
+		response.Checks[name] = StatusOK
+	}
+
+	return response
+}
+
+func (r *Registry) Liveness(w http.ResponseWriter, _ *http.Request) {
+	write(w, Response{
+		Status: StatusOK,
+	})
+}
+
+func (r *Registry) Readiness(w http.ResponseWriter, req *http.Request) {
+	write(w, r.Run(req.Context()))
+}
+
+func NotClosed(c Closer) Check {
+	return func(_ context.Context) error {
+		if c.IsClosed() {
+			return ErrClosed
+		}
+
+		return nil
+	}
+}
+
+func (a *Activity) Start() {
+	a.running.Store(true)
+}
+
+func (a *Activity) Stop() {
+	a.running.Store(false)
+}
+
+func (a *Activity) Check(_ context.Context) error {
+	if !a.running.Load() {
+		return ErrStopped
+	}
+
+	return nil
+}
+
+func write(w http.ResponseWriter, response Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if response.Status != StatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_ = json.NewEncoder(w).Encode(response)
+}
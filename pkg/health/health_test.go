@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type (
+	fakeCloser bool
+)
+
+func (c fakeCloser) IsClosed() bool {
+	return bool(c)
+}
+
+func TestRegistry_Readiness(t *testing.T) {
+	activity := &Activity{}
+	activity.Start()
+
+	stopped := &Activity{}
+
+	tests := []struct {
+		name       string
+		checks     map[string]Check
+		wantStatus int
+		want       Response
+	}{
+		{
+			name: "Should be ready when every check passes",
+			checks: map[string]Check{
+				"connection": NotClosed(fakeCloser(false)),
+				"consumer":   activity.Check,
+			},
+			wantStatus: http.StatusOK,
+			want: Response{
+				Status: StatusOK,
+				Checks: map[string]string{
+					"connection": StatusOK,
+					"consumer":   StatusOK,
+				},
+			},
+		},
+		{
+			name: "Should not be ready when any check fails",
+			checks: map[string]Check{
+				"connection": NotClosed(fakeCloser(true)),
+				"consumer":   stopped.Check,
+				"upstream": func(_ context.Context) error {
+					return errors.New("some error")
+				},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			want: Response{
+				Status: StatusUnavailable,
+				Checks: map[string]string{
+					"connection": ErrClosed.Error(),
+					"consumer":   ErrStopped.Error(),
+					"upstream":   "some error",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			for name, check := range tt.checks {
+				r.Register(name, check)
+			}
+
+			recorder := httptest.NewRecorder()
+			r.Readiness(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("Readiness() status = %v, want %v", recorder.Code, tt.wantStatus)
+			}
+
+			var got Response
+			if err := json.NewDecoder(recorder.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Readiness() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
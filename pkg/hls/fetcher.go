@@ -0,0 +1,317 @@
+package hls
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	SelectBandwidth  = "bandwidth"
+	SelectResolution = "resolution"
+
+	maxPlaylistSize = 1 << 20
+)
+
+var (
+	ErrNestedPlaylist = errors.New("variant playlist is a master playlist")
+
+	errRejected = errors.New("rejected")
+)
+
+type (
+	Options struct {
+		Parallel     int
+		Retries      int
+		Backoff      time.Duration
+		Select       string
+		MaxBandwidth int64
+		MaxHeight    int
+	}
+
+	Fetcher struct {
+		httpClient *http.Client
+		options    Options
+	}
+
+	Stream struct {
+		URL      string
+		Variant  Variant
+		Playlist Playlist
+	}
+
+	result struct {
+		body []byte
+		err  error
+	}
+)
+
+func New(httpClient *http.Client, options Options) *Fetcher {
+	if options.Parallel < 1 {
+		options.Parallel = 4
+	}
+
+	if options.Retries < 0 {
+		options.Retries = 0
+	}
+
+	if options.Backoff <= 0 {
+		options.Backoff = 500 * time.Millisecond
+	}
+
+	if options.Select == "" {
+		options.Select = SelectBandwidth
+	}
+
+	return &Fetcher{
+		httpClient: httpClient,
+		options:    options,
+	}
+}
+
+func (f Fetcher) Fetch(ctx context.Context, rawURL string, w io.Writer) (Stream, int64, error) {
+	stream, err := f.Open(ctx, rawURL)
+	if err != nil {
+		return Stream{}, 0, err
+	}
+
+	written, err := f.Write(ctx, stream, w)
+	return stream, written, err
+}
+
+func (f Fetcher) Open(ctx context.Context, rawURL string) (Stream, error) {
+	playlist, err := f.playlist(ctx, rawURL)
+	if err != nil {
+		return Stream{}, err
+	}
+
+	stream := Stream{URL: rawURL, Playlist: playlist}
+	if !playlist.IsMaster() {
+		return stream, nil
+	}
+
+	stream.Variant = f.options.choose(playlist)
+	if audio, ok := playlist.SeparateAudio(stream.Variant); ok {
+		return Stream{}, fmt.Errorf("%w: %s", ErrSeparateAudio, audio.URI)
+	}
+
+	stream.URL = stream.Variant.URI
+	if stream.Playlist, err = f.playlist(ctx, stream.URL); err != nil {
+		return Stream{}, err
+	}
+
+	if stream.Playlist.IsMaster() {
+		return Stream{}, fmt.Errorf("%w: %s", ErrNestedPlaylist, stream.URL)
+	}
+
+	return stream, nil
+}
+
+func (f Fetcher) Write(ctx context.Context, s Stream, w io.Writer) (int64, error) {
+	segments := s.Playlist.Segments
+	if s.Playlist.Map != nil {
+		segments = append([]Segment{*s.Playlist.Map}, segments...)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]chan result, len(segments))
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+
+	slots := make(chan struct{}, f.options.Parallel)
+	go func() {
+		for i, segment := range segments {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			go func(i int, segment Segment) {
+				body, err := f.segment(ctx, segment)
+				results[i] <- result{body: body, err: err}
+			}(i, segment)
+		}
+	}()
+
+	var written int64
+	for i := range segments {
+		var r result
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			return written, ctx.Err()
+		}
+
+		if r.err != nil {
+			return written, fmt.Errorf("failed to fetch segment %d: %w", i, r.err)
+		}
+
+		n, err := w.Write(r.body)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+
+		<-slots
+	}
+
+	return written, nil
+}
+
+func (s Stream) ContentType() string {
+	return s.Playlist.ContentType()
+}
+
+func (s Stream) Ext() string {
+	return s.Playlist.Ext()
+}
+
+func (o Options) choose(playlist Playlist) Variant {
+	var muxed []Variant
+	for _, v := range playlist.Variants {
+		if _, ok := playlist.SeparateAudio(v); !ok {
+			muxed = append(muxed, v)
+		}
+	}
+
+	variants := playlist.Variants
+	if len(muxed) > 0 {
+		variants = muxed
+	}
+
+	var chosen, lowest Variant
+	found := false
+	for i, v := range variants {
+		if i == 0 || v.Bandwidth < lowest.Bandwidth {
+			lowest = v
+		}
+
+		if (o.MaxBandwidth > 0 && v.Bandwidth > o.MaxBandwidth) || (o.MaxHeight > 0 && v.Height > o.MaxHeight) {
+			continue
+		}
+
+		if !found || o.better(v, chosen) {
+			chosen, found = v, true
+		}
+	}
+
+	if !found {
+		return lowest
+	}
+
+	return chosen
+}
+
+func (o Options) better(v, than Variant) bool {
+	if o.Select == SelectResolution {
+		if pixels, other := v.Width*v.Height, than.Width*than.Height; pixels != other {
+			return pixels > other
+		}
+	}
+
+	return v.Bandwidth > than.Bandwidth
+}
+
+func (f Fetcher) playlist(ctx context.Context, rawURL string) (Playlist, error) {
+	body, err := f.retry(ctx, Segment{URI: rawURL}, maxPlaylistSize)
+	if err != nil {
+		return Playlist{}, err
+	}
+
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return Playlist{}, err
+	}
+
+	return Parse(bytes.NewReader(body), base)
+}
+
+func (f Fetcher) segment(ctx context.Context, s Segment) ([]byte, error) {
+	return f.retry(ctx, s, -1)
+}
+
+func (f Fetcher) retry(ctx context.Context, s Segment, limit int64) ([]byte, error) {
+	var err error
+	for attempt := 0; attempt <= f.options.Retries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(f.options.Backoff * time.Duration(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		var body []byte
+		if body, err = f.get(ctx, s, limit); err == nil {
+			return body, nil
+		}
+
+		if ctx.Err() != nil || errors.Is(err, status.ErrNotFound) || errors.Is(err, ErrInvalidPlaylist) || errors.Is(err, errRejected) {
+			return nil, err
+		}
+	}
+
+	return nil, err
+}
+
+func (f Fetcher) get(ctx context.Context, s Segment, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Length > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", s.Offset, s.Offset+s.Length-1))
+	}
+
+	res, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return nil, fmt.Errorf("%w: %s", status.ErrNotFound, s.URI)
+	case res.StatusCode >= http.StatusBadRequest && res.StatusCode < http.StatusInternalServerError &&
+		res.StatusCode != http.StatusRequestTimeout && res.StatusCode != http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%w: %w with %d (%s): %s", status.ErrBadStatus, errRejected, res.StatusCode, res.Status, s.URI)
+	case res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent:
+		return nil, fmt.Errorf("%w: %d (%s): %s", status.ErrBadStatus, res.StatusCode, res.Status, s.URI)
+	}
+
+	var body io.Reader = res.Body
+	if limit > 0 {
+		body = io.LimitReader(res.Body, limit)
+	}
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Length > 0 && res.StatusCode == http.StatusOK {
+		if int64(len(content)) < s.Offset+s.Length {
+			return nil, fmt.Errorf("%w: %s is shorter than its byte range", status.ErrBadStatus, s.URI)
+		}
+
+		content = content[s.Offset : s.Offset+s.Length]
+	}
+
+	return content, nil
+}
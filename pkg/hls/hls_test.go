@@ -0,0 +1,189 @@
+package hls
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/pkg/hls/testdata"
+	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts[r.URL.Path]++
+		attempt := attempts[r.URL.Path]
+		mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/master.m3u8":
+			_, _ = w.Write([]byte(testdata.MasterPlaylist))
+		case r.URL.Path == "/mixed-audio.m3u8":
+			_, _ = w.Write([]byte(testdata.MixedAudioPlaylist))
+		case r.URL.Path == "/separate-audio.m3u8":
+			_, _ = w.Write([]byte(testdata.SeparateAudioPlaylist))
+		case r.URL.Path == "/encrypted.m3u8":
+			_, _ = w.Write([]byte(testdata.EncryptedPlaylist))
+		case r.URL.Path == "/fmp4/index.m3u8":
+			_, _ = w.Write([]byte(testdata.FMP4Playlist))
+		case r.URL.Path == "/fmp4/video.mp4":
+			http.ServeContent(w, r, "video.mp4", time.Time{}, strings.NewReader("initmoo1moo2"))
+		case r.URL.Path == "/missing/index.m3u8":
+			_, _ = w.Write([]byte(strings.Replace(testdata.TSPlaylist, "segment2.ts", "gone.ts", 1)))
+		case strings.HasSuffix(r.URL.Path, "/index.m3u8"):
+			_, _ = w.Write([]byte(testdata.TSPlaylist))
+		case strings.HasSuffix(r.URL.Path, "/segment1.ts") && attempt == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case strings.HasSuffix(r.URL.Path, ".ts") && !strings.HasSuffix(r.URL.Path, "/gone.ts"):
+			variant, segment, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+			_, _ = w.Write([]byte(variant + ":" + strings.TrimSuffix(segment, ".ts") + ";"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetcher_Fetch(t *testing.T) {
+	server := newServer(t)
+
+	tests := []struct {
+		name            string
+		path            string
+		options         Options
+		want            string
+		wantContentType string
+		wantErr         error
+	}{
+		{
+			name:            "Should choose the highest bandwidth and concatenate the segments in order",
+			path:            "/master.m3u8",
+			want:            "wide:segment0;wide:segment1;wide:segment2;wide:segment3;",
+			wantContentType: ContentTypeTS,
+		},
+		{
+			name:            "Should choose the highest resolution",
+			path:            "/master.m3u8",
+			options:         Options{Select: SelectResolution},
+			want:            "mid:segment0;mid:segment1;mid:segment2;mid:segment3;",
+			wantContentType: ContentTypeTS,
+		},
+		{
+			name:            "Should respect the resolution cap",
+			path:            "/master.m3u8",
+			options:         Options{Select: SelectResolution, MaxHeight: 540},
+			want:            "wide:segment0;wide:segment1;wide:segment2;wide:segment3;",
+			wantContentType: ContentTypeTS,
+		},
+		{
+			name:            "Should fall back to the lowest bandwidth when nothing fits the caps",
+			path:            "/master.m3u8",
+			options:         Options{MaxBandwidth: 1000},
+			want:            "low:segment0;low:segment1;low:segment2;low:segment3;",
+			wantContentType: ContentTypeTS,
+		},
+		{
+			name:            "Should download media playlists directly",
+			path:            "/direct/index.m3u8",
+			options:         Options{Parallel: 1},
+			want:            "direct:segment0;direct:segment1;direct:segment2;direct:segment3;",
+			wantContentType: ContentTypeTS,
+		},
+		{
+			name:            "Should write the init section and byte ranges of fragmented mp4",
+			path:            "/fmp4/index.m3u8",
+			want:            "initmoo1moo2",
+			wantContentType: ContentTypeFMP4,
+		},
+		{
+			name:    "Should fail when a segment is missing",
+			path:    "/missing/index.m3u8",
+			wantErr: status.ErrNotFound,
+		},
+		{
+			name:            "Should prefer variants with muxed audio",
+			path:            "/mixed-audio.m3u8",
+			want:            "muxed:segment0;muxed:segment1;muxed:segment2;muxed:segment3;",
+			wantContentType: ContentTypeTS,
+		},
+		{
+			name:    "Should reject variants whose audio is a separate rendition",
+			path:    "/separate-audio.m3u8",
+			wantErr: ErrSeparateAudio,
+		},
+		{
+			name:    "Should reject encrypted playlists",
+			path:    "/encrypted.m3u8",
+			wantErr: ErrEncrypted,
+		},
+		{
+			name:    "Should return not found for missing playlists",
+			path:    "/nothing.m3u8",
+			wantErr: status.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.options.Retries, tt.options.Backoff = 2, time.Millisecond
+
+			var buffer bytes.Buffer
+			stream, written, err := New(server.Client(), tt.options).Fetch(context.Background(), server.URL+tt.path, &buffer)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Fetch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			if got := buffer.String(); got != tt.want || written != int64(len(tt.want)) {
+				t.Errorf("Fetch() wrote %q (%d bytes), want %q", got, written, tt.want)
+			}
+
+			if got := stream.ContentType(); got != tt.wantContentType {
+				t.Errorf("ContentType() = %v, want %v", got, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	playlist, err := Parse(strings.NewReader(testdata.FMP4Playlist), nil)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if playlist.Map == nil || playlist.Map.Length != 4 || playlist.Map.Offset != 0 {
+		t.Errorf("Parse() map = %+v, want 4 bytes at 0", playlist.Map)
+	}
+
+	if len(playlist.Segments) != 2 || playlist.Segments[1].Offset != 8 || !playlist.Ended || playlist.TargetDuration != 2 {
+		t.Errorf("Parse() = %+v, want two contiguous byte ranges", playlist)
+	}
+
+	master, err := Parse(strings.NewReader(testdata.SeparateAudioPlaylist), nil)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if audio, ok := master.SeparateAudio(master.Variants[0]); !ok || audio.URI != "audio/index.m3u8" || !audio.Default {
+		t.Errorf("SeparateAudio() = %+v, %v, want the default audio rendition", audio, ok)
+	}
+
+	if _, err := Parse(strings.NewReader("not a playlist"), nil); !errors.Is(err, ErrInvalidPlaylist) {
+		t.Errorf("Parse() error = %v, want %v", err, ErrInvalidPlaylist)
+	}
+
+	if !IsPlaylist("https://v.redd.it/some/HLSPlaylist.m3u8?a=1") || IsPlaylist("https://i.imgur.com/some.mp4") {
+		t.Errorf("IsPlaylist() did not recognize playlist URLs")
+	}
+}
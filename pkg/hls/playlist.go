@@ -0,0 +1,336 @@
+package hls
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	ContentTypeTS   = "video/mp2t"
+	ContentTypeFMP4 = "video/mp4"
+
+	headerTag    = "#EXTM3U"
+	streamTag    = "#EXT-X-STREAM-INF:"
+	mediaTag     = "#EXT-X-MEDIA:"
+	segmentTag   = "#EXTINF:"
+	byteRangeTag = "#EXT-X-BYTERANGE:"
+	mapTag       = "#EXT-X-MAP:"
+	keyTag       = "#EXT-X-KEY:"
+	durationTag  = "#EXT-X-TARGETDURATION:"
+	endTag       = "#EXT-X-ENDLIST"
+)
+
+var (
+	ErrInvalidPlaylist = errors.New("invalid playlist")
+	ErrEncrypted       = errors.New("encrypted playlists are not supported")
+	ErrSeparateAudio   = errors.New("audio in a separate rendition is not supported")
+)
+
+type (
+	Playlist struct {
+		Variants       []Variant
+		Renditions     []Rendition
+		Segments       []Segment
+		Map            *Segment
+		TargetDuration int
+		Ended          bool
+	}
+
+	Variant struct {
+		URI       string
+		Bandwidth int64
+		Width     int
+		Height    int
+		Codecs    string
+		Audio     string
+	}
+
+	Rendition struct {
+		Type    string
+		GroupID string
+		Name    string
+		URI     string
+		Default bool
+	}
+
+	Segment struct {
+		URI      string
+		Duration float64
+		Offset   int64
+		Length   int64
+	}
+)
+
+func Parse(r io.Reader, base *url.URL) (Playlist, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var (
+		playlist Playlist
+		variant  *Variant
+		segment  *Segment
+		ranges   = map[string]int64{}
+		header   bool
+	)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if !header {
+			if line != headerTag {
+				return Playlist{}, fmt.Errorf("%w: missing %s header", ErrInvalidPlaylist, headerTag)
+			}
+
+			header = true
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, streamTag):
+			attributes := parseAttributes(strings.TrimPrefix(line, streamTag))
+			bandwidth, _ := strconv.ParseInt(attributes["BANDWIDTH"], 10, 64)
+			width, height := parseResolution(attributes["RESOLUTION"])
+			variant = &Variant{Bandwidth: bandwidth, Width: width, Height: height, Codecs: attributes["CODECS"], Audio: attributes["AUDIO"]}
+		case strings.HasPrefix(line, mediaTag):
+			attributes := parseAttributes(strings.TrimPrefix(line, mediaTag))
+			rendition := Rendition{
+				Type:    attributes["TYPE"],
+				GroupID: attributes["GROUP-ID"],
+				Name:    attributes["NAME"],
+				Default: attributes["DEFAULT"] == "YES",
+			}
+
+			if uri, ok := attributes["URI"]; ok {
+				var err error
+				if rendition.URI, err = resolve(base, uri); err != nil {
+					return Playlist{}, err
+				}
+			}
+
+			playlist.Renditions = append(playlist.Renditions, rendition)
+		case strings.HasPrefix(line, segmentTag):
+			duration, _, _ := strings.Cut(strings.TrimPrefix(line, segmentTag), ",")
+			seconds, err := strconv.ParseFloat(duration, 64)
+			if err != nil {
+				return Playlist{}, fmt.Errorf("%w: bad segment duration %q", ErrInvalidPlaylist, duration)
+			}
+
+			if segment == nil {
+				segment = &Segment{}
+			}
+
+			segment.Duration = seconds
+		case strings.HasPrefix(line, byteRangeTag):
+			if segment == nil {
+				segment = &Segment{}
+			}
+
+			length, offset, ok := parseByteRange(strings.TrimPrefix(line, byteRangeTag))
+			if !ok {
+				return Playlist{}, fmt.Errorf("%w: bad byte range %q", ErrInvalidPlaylist, line)
+			}
+
+			segment.Length, segment.Offset = length, offset
+		case strings.HasPrefix(line, mapTag):
+			attributes := parseAttributes(strings.TrimPrefix(line, mapTag))
+			uri, err := resolve(base, attributes["URI"])
+			if err != nil {
+				return Playlist{}, err
+			}
+
+			init := Segment{URI: uri}
+			if value, ok := attributes["BYTERANGE"]; ok {
+				if init.Length, init.Offset, ok = parseByteRange(value); !ok || init.Offset < 0 {
+					return Playlist{}, fmt.Errorf("%w: bad map byte range %q", ErrInvalidPlaylist, value)
+				}
+			}
+
+			playlist.Map = &init
+		case strings.HasPrefix(line, keyTag):
+			if method := parseAttributes(strings.TrimPrefix(line, keyTag))["METHOD"]; method != "NONE" {
+				return Playlist{}, fmt.Errorf("%w: %s", ErrEncrypted, method)
+			}
+		case strings.HasPrefix(line, durationTag):
+			playlist.TargetDuration, _ = strconv.Atoi(strings.TrimPrefix(line, durationTag))
+		case line == endTag:
+			playlist.Ended = true
+		case strings.HasPrefix(line, "#"):
+		default:
+			uri, err := resolve(base, line)
+			if err != nil {
+				return Playlist{}, err
+			}
+
+			if variant != nil {
+				variant.URI = uri
+				playlist.Variants = append(playlist.Variants, *variant)
+				variant = nil
+				continue
+			}
+
+			if segment == nil {
+				return Playlist{}, fmt.Errorf("%w: %s has no %s", ErrInvalidPlaylist, line, strings.TrimSuffix(segmentTag, ":"))
+			}
+
+			segment.URI = uri
+			if segment.Length > 0 {
+				if segment.Offset < 0 {
+					segment.Offset = ranges[uri]
+				}
+
+				ranges[uri] = segment.Offset + segment.Length
+			}
+
+			playlist.Segments = append(playlist.Segments, *segment)
+			segment = nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Playlist{}, fmt.Errorf("%w: %v", ErrInvalidPlaylist, err)
+	}
+
+	if !header {
+		return Playlist{}, fmt.Errorf("%w: empty playlist", ErrInvalidPlaylist)
+	}
+
+	if len(playlist.Variants) == 0 && len(playlist.Segments) == 0 {
+		return Playlist{}, fmt.Errorf("%w: no variants or segments", ErrInvalidPlaylist)
+	}
+
+	return playlist, nil
+}
+
+func (p Playlist) IsMaster() bool {
+	return len(p.Variants) > 0
+}
+
+func (p Playlist) SeparateAudio(v Variant) (Rendition, bool) {
+	if v.Audio == "" {
+		return Rendition{}, false
+	}
+
+	for _, r := range p.Renditions {
+		if r.Type == "AUDIO" && r.GroupID == v.Audio && r.URI != "" {
+			return r, true
+		}
+	}
+
+	return Rendition{}, false
+}
+
+func (p Playlist) ContentType() string {
+	if p.Map != nil {
+		return ContentTypeFMP4
+	}
+
+	for _, s := range p.Segments {
+		if ext := extension(s.URI); ext == ".m4s" || ext == ".mp4" {
+			return ContentTypeFMP4
+		}
+	}
+
+	return ContentTypeTS
+}
+
+func (p Playlist) Ext() string {
+	if p.ContentType() == ContentTypeFMP4 {
+		return ".mp4"
+	}
+
+	return ".ts"
+}
+
+func IsPlaylist(rawURL string) bool {
+	ext := extension(rawURL)
+	return ext == ".m3u8" || ext == ".m3u"
+}
+
+func parseAttributes(text string) map[string]string {
+	attributes := map[string]string{}
+	for text != "" {
+		key, rest, found := strings.Cut(text, "=")
+		if !found {
+			break
+		}
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+
+			rest = strings.TrimPrefix(rest, ",")
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+
+		attributes[strings.TrimSpace(key)] = value
+		text = rest
+	}
+
+	return attributes
+}
+
+func parseResolution(text string) (int, int) {
+	w, h, found := strings.Cut(text, "x")
+	if !found {
+		return 0, 0
+	}
+
+	width, _ := strconv.Atoi(w)
+	height, _ := strconv.Atoi(h)
+	return width, height
+}
+
+func parseByteRange(text string) (int64, int64, bool) {
+	n, o, found := strings.Cut(text, "@")
+	length, err := strconv.ParseInt(n, 10, 64)
+	if err != nil || length <= 0 {
+		return 0, 0, false
+	}
+
+	if !found {
+		return length, -1, true
+	}
+
+	offset, err := strconv.ParseInt(o, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, 0, false
+	}
+
+	return length, offset, true
+}
+
+func resolve(base *url.URL, ref string) (string, error) {
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("%w: bad uri %q", ErrInvalidPlaylist, ref)
+	}
+
+	if base == nil {
+		return parsed.String(), nil
+	}
+
+	return base.ResolveReference(parsed).String(), nil
+}
+
+func extension(rawURL string) string {
+	if parsed, err := url.Parse(rawURL); err == nil {
+		rawURL = parsed.Path
+	}
+
+	return strings.ToLower(path.Ext(rawURL))
+}
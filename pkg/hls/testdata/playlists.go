@@ -0,0 +1,63 @@
+package testdata
+
+const MasterPlaylist = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360,CODECS="avc1.4d401e,mp4a.40.2"
+low/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2400000,RESOLUTION=1280x720,CODECS="avc1.4d401f,mp4a.40.2"
+mid/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=3000000,RESOLUTION=960x540,CODECS="avc1.4d401f,mp4a.40.2"
+wide/index.m3u8
+`
+
+const TSPlaylist = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:0
+#EXTINF:4.000,
+segment0.ts
+#EXTINF:4.000,
+segment1.ts
+#EXTINF:4.000,
+segment2.ts
+#EXTINF:2.500,
+segment3.ts
+#EXT-X-ENDLIST
+`
+
+const FMP4Playlist = `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:2
+#EXT-X-MAP:URI="video.mp4",BYTERANGE="4@0"
+#EXTINF:2.000,
+#EXT-X-BYTERANGE:4@4
+video.mp4
+#EXTINF:2.000,
+#EXT-X-BYTERANGE:4
+video.mp4
+#EXT-X-ENDLIST
+`
+
+const EncryptedPlaylist = `#EXTM3U
+#EXT-X-TARGETDURATION:4
+#EXT-X-KEY:METHOD=AES-128,URI="key.bin"
+#EXTINF:4.000,
+segment0.ts
+#EXT-X-ENDLIST
+`
+
+const SeparateAudioPlaylist = `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="audio",NAME="Audio",DEFAULT=YES,URI="audio/index.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1200000,RESOLUTION=1280x720,CODECS="avc1.4d401f,mp4a.40.2",AUDIO="audio"
+video/index.m3u8
+`
+
+const MixedAudioPlaylist = `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="audio",NAME="Audio",DEFAULT=YES,URI="audio/index.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=2400000,RESOLUTION=1920x1080,CODECS="avc1.640028,mp4a.40.2",AUDIO="audio"
+separate/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=1200000,RESOLUTION=1280x720,CODECS="avc1.4d401f,mp4a.40.2"
+muxed/index.m3u8
+`
@@ -0,0 +1,31 @@
+package httpcache
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func StatsHandler(c *Cache) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if err := json.NewEncoder(w).Encode(c.Stats()); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
+
+func PurgeHandler(c *Cache, keys func(id string) []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var purge []string
+		for _, id := range r.URL.Query()["id"] {
+			purge = append(purge, keys(id)...)
+		}
+
+		purge = append(purge, r.URL.Query()["url"]...)
+		if err := c.Purge(r.Context(), purge...); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,269 @@
+package httpcache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	HeaderCache = "X-Cache"
+
+	StatusHit         = "HIT"
+	StatusMiss        = "MISS"
+	StatusRevalidated = "REVALIDATED"
+
+	defaultFetchTimeout = 30 * time.Second
+)
+
+type (
+	Options struct {
+		TTL          time.Duration
+		NotFoundTTL  time.Duration
+		FetchTimeout time.Duration
+	}
+
+	Cache struct {
+		store       Store
+		options     Options
+		calls       group
+		hits        atomic.Uint64
+		misses      atomic.Uint64
+		revalidated atomic.Uint64
+		now         func() time.Time
+	}
+
+	Stats struct {
+		Enabled     bool   `json:"enabled"`
+		Hits        uint64 `json:"hits"`
+		Misses      uint64 `json:"misses"`
+		Revalidated uint64 `json:"revalidated"`
+	}
+
+	roundTripper struct {
+		cache *Cache
+		next  http.RoundTripper
+	}
+
+	result struct {
+		entry  Entry
+		status string
+	}
+
+	call struct {
+		done   chan struct{}
+		result result
+		err    error
+	}
+
+	group struct {
+		mu    sync.Mutex
+		calls map[string]*call
+	}
+
+	noCacheKey struct{}
+)
+
+func New(store Store, options Options) *Cache {
+	return &Cache{
+		store:   store,
+		options: options,
+		now:     time.Now,
+	}
+}
+
+func WithNoCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+func NoCache(ctx context.Context) bool {
+	noCache, _ := ctx.Value(noCacheKey{}).(bool)
+	return noCache
+}
+
+func (c *Cache) Wrap(next http.RoundTripper) http.RoundTripper {
+	return &roundTripper{
+		cache: c,
+		next:  next,
+	}
+}
+
+func (c *Cache) Purge(ctx context.Context, keys ...string) error {
+	if c == nil {
+		return nil
+	}
+
+	if len(keys) == 0 {
+		return c.store.Purge(ctx)
+	}
+
+	for _, key := range keys {
+		if err := c.store.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+
+	return Stats{
+		Enabled:     true,
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Revalidated: c.revalidated.Load(),
+	}
+}
+
+func (c *Cache) ttl(statusCode int) time.Duration {
+	switch statusCode {
+	case http.StatusOK:
+		return c.options.TTL
+	case http.StatusNotFound:
+		return c.options.NotFoundTTL
+	default:
+		return 0
+	}
+}
+
+func (t roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method != http.MethodGet || r.Header.Get("Range") != "" {
+		return t.next.RoundTrip(r)
+	}
+
+	key := r.URL.String()
+	stale, err := t.cache.store.Get(r.Context(), key)
+	cached := err == nil
+	if cached && !NoCache(r.Context()) && t.cache.now().Before(stale.Expires) {
+		t.cache.hits.Add(1)
+		return stale.response(r, StatusHit), nil
+	}
+
+	res, err := t.cache.calls.do(r.Context(), key, func() (result, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), t.cache.options.fetchTimeout())
+		defer cancel()
+
+		if !cached {
+			return t.fetch(r.WithContext(ctx), key, nil)
+		}
+
+		return t.fetch(r.WithContext(ctx), key, &stale)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.entry.response(r, res.status), nil
+}
+
+func (t roundTripper) fetch(r *http.Request, key string, stale *Entry) (result, error) {
+	req := r.Clone(r.Context())
+	if stale != nil && stale.Header.Get("ETag") != "" {
+		req.Header.Set("If-None-Match", stale.Header.Get("ETag"))
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return result{}, err
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	now := t.cache.now()
+	if res.StatusCode == http.StatusNotModified && stale != nil {
+		stale.Expires = now.Add(t.cache.ttl(stale.StatusCode))
+		_ = t.cache.store.Set(r.Context(), key, *stale)
+		t.cache.revalidated.Add(1)
+		return result{entry: *stale, status: StatusRevalidated}, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return result{}, err
+	}
+
+	entry := Entry{
+		URL:        key,
+		StatusCode: res.StatusCode,
+		Header:     res.Header.Clone(),
+		Body:       body,
+		Expires:    now.Add(t.cache.ttl(res.StatusCode)),
+	}
+
+	t.cache.misses.Add(1)
+	if t.cache.ttl(res.StatusCode) > 0 && !strings.Contains(res.Header.Get("Cache-Control"), "no-store") {
+		_ = t.cache.store.Set(r.Context(), key, entry)
+	}
+
+	return result{entry: entry, status: StatusMiss}, nil
+}
+
+func (e Entry) response(r *http.Request, status string) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	header.Set(HeaderCache, status)
+	header.Set("Content-Length", strconv.Itoa(len(e.Body)))
+	return &http.Response{
+		Status:        strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       r,
+	}
+}
+
+func (o Options) fetchTimeout() time.Duration {
+	if o.FetchTimeout > 0 {
+		return o.FetchTimeout
+	}
+
+	return defaultFetchTimeout
+}
+
+func (g *group) do(ctx context.Context, key string, fn func() (result, error)) (result, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*call{}
+	}
+
+	c, exists := g.calls[key]
+	if !exists {
+		c = &call{done: make(chan struct{})}
+		g.calls[key] = c
+		go func() {
+			c.result, c.err = fn()
+
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(c.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.result, c.err
+	case <-ctx.Done():
+		return result{}, ctx.Err()
+	}
+}
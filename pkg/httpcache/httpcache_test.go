@@ -0,0 +1,308 @@
+package httpcache
+
+import (
+	"context"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/pkg/storage"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	someURL = "https://api.imgur.com/3/image/some-id"
+)
+
+type (
+	roundTripperFunc func(r *http.Request) (*http.Response, error)
+)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestCache_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		advance    time.Duration
+		ctx        context.Context
+		wantCalls  int32
+		wantCache  string
+	}{
+		{
+			name:       "Should serve fresh responses from the cache",
+			statusCode: http.StatusOK,
+			advance:    30 * time.Minute,
+			ctx:        context.Background(),
+			wantCalls:  1,
+			wantCache:  StatusHit,
+		},
+		{
+			name:       "Should revalidate expired responses with their ETag",
+			statusCode: http.StatusOK,
+			advance:    time.Hour,
+			ctx:        context.Background(),
+			wantCalls:  2,
+			wantCache:  StatusRevalidated,
+		},
+		{
+			name:       "Should revalidate when the caller asks for no-cache",
+			statusCode: http.StatusOK,
+			ctx:        WithNoCache(context.Background()),
+			wantCalls:  2,
+			wantCache:  StatusRevalidated,
+		},
+		{
+			name:       "Should keep not found responses for their own TTL",
+			statusCode: http.StatusNotFound,
+			advance:    time.Minute,
+			ctx:        context.Background(),
+			wantCalls:  1,
+			wantCache:  StatusHit,
+		},
+		{
+			name:       "Should expire not found responses sooner",
+			statusCode: http.StatusNotFound,
+			advance:    5 * time.Minute,
+			ctx:        context.Background(),
+			wantCalls:  2,
+			wantCache:  StatusRevalidated,
+		},
+		{
+			name:       "Should not cache server errors",
+			statusCode: http.StatusInternalServerError,
+			ctx:        context.Background(),
+			wantCalls:  2,
+			wantCache:  StatusMiss,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			next := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				calls.Add(1)
+				if r.Header.Get("If-None-Match") == `"some-etag"` {
+					return response(http.StatusNotModified, ""), nil
+				}
+
+				return response(tt.statusCode, `{"data":{"id":"some-id"}}`), nil
+			})
+
+			now := time.Now()
+			cache := New(NewMemory(10), Options{TTL: time.Hour, NotFoundTTL: 5 * time.Minute})
+			cache.now = func() time.Time {
+				return now
+			}
+
+			client := &http.Client{Transport: cache.Wrap(next)}
+			if res := get(t, client, context.Background()); res.Header.Get(HeaderCache) != StatusMiss {
+				t.Fatalf("first %s = %q, want %q", HeaderCache, res.Header.Get(HeaderCache), StatusMiss)
+			}
+
+			now = now.Add(tt.advance)
+			res := get(t, client, tt.ctx)
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("upstream calls = %d, want %d", got, tt.wantCalls)
+			}
+
+			if got := res.Header.Get(HeaderCache); got != tt.wantCache {
+				t.Errorf("%s = %q, want %q", HeaderCache, got, tt.wantCache)
+			}
+
+			body, _ := io.ReadAll(res.Body)
+			if res.StatusCode != tt.statusCode || string(body) != `{"data":{"id":"some-id"}}` {
+				t.Errorf("response = %d %s, want %d with the original body", res.StatusCode, body, tt.statusCode)
+			}
+		})
+	}
+}
+
+func TestCache_Singleflight(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	next := roundTripperFunc(func(_ *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		<-release
+		return response(http.StatusOK, "{}"), nil
+	})
+
+	client := &http.Client{Transport: New(NewMemory(10), Options{TTL: time.Hour}).Wrap(next)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = get(t, client, context.Background())
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("upstream calls = %d, want 1", got)
+	}
+}
+
+func TestCache_Singleflight_LeaderCanceled(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	next := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+
+		<-release
+		if err := r.Context().Err(); err != nil {
+			return nil, err
+		}
+
+		return response(http.StatusOK, "{}"), nil
+	})
+
+	client := &http.Client{Transport: New(NewMemory(10), Options{TTL: time.Hour}).Wrap(next)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, someURL, nil)
+		_, err := client.Do(req)
+		leader <- err
+	}()
+
+	<-started
+	waiter := make(chan *http.Response, 1)
+	go func() {
+		waiter <- get(t, client, context.Background())
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader error = %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	if res := <-waiter; res.StatusCode != http.StatusOK {
+		t.Errorf("waiter status = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("upstream calls = %d, want 1", got)
+	}
+}
+
+func TestStore(t *testing.T) {
+	tests := []struct {
+		name  string
+		store Store
+	}{
+		{name: "Should keep entries in memory", store: NewMemory(10)},
+		{name: "Should keep entries in a storage backend", store: NewStorageStore(storage.NewMemory())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			entry := Entry{URL: someURL, StatusCode: http.StatusOK, Header: http.Header{"Etag": {`"some-etag"`}}, Body: []byte("{}")}
+			for _, key := range []string{someURL, someURL + "-other", someURL + "-another"} {
+				if err := tt.store.Set(ctx, key, entry); err != nil {
+					t.Fatalf("Set() error = %v", err)
+				}
+			}
+
+			got, err := tt.store.Get(ctx, someURL)
+			if err != nil || string(got.Body) != "{}" || got.Header.Get("ETag") != `"some-etag"` {
+				t.Errorf("Get() = %+v, %v, want the stored entry", got, err)
+			}
+
+			_ = tt.store.Delete(ctx, someURL)
+			if _, err := tt.store.Get(ctx, someURL); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() after Delete() error = %v, want %v", err, ErrNotFound)
+			}
+
+			_ = tt.store.Purge(ctx)
+			if _, err := tt.store.Get(ctx, someURL+"-other"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() after Purge() error = %v, want %v", err, ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestStorageStore_Purge(t *testing.T) {
+	ctx := context.Background()
+	backend := storage.NewMemory()
+	_ = backend.Put(ctx, "cache/some-image.jpg", strings.NewReader("x"), storage.Info{Key: "cache/some-image.jpg", Size: 1})
+
+	store := NewStorageStore(backend)
+	_ = store.Set(ctx, someURL, Entry{URL: someURL})
+	if err := store.Purge(ctx); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+
+	if _, err := store.Get(ctx, someURL); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Purge() error = %v, want %v", err, ErrNotFound)
+	}
+
+	if _, _, err := backend.Get(ctx, "cache/some-image.jpg"); err != nil {
+		t.Errorf("Get() of a downloaded file error = %v, want it kept", err)
+	}
+}
+
+func TestPurgeHandler(t *testing.T) {
+	ctx := context.Background()
+	cache := New(NewMemory(10), Options{TTL: time.Hour})
+	_ = cache.store.Set(ctx, someURL, Entry{})
+	_ = cache.store.Set(ctx, someURL+"-other", Entry{})
+
+	handler := PurgeHandler(cache, func(id string) []string {
+		return []string{"https://api.imgur.com/3/image/" + id}
+	})
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodDelete, "/admin/cache?id=some-id", nil))
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusNoContent)
+	}
+
+	if _, err := cache.store.Get(ctx, someURL); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of the purged ID error = %v, want %v", err, ErrNotFound)
+	}
+
+	if _, err := cache.store.Get(ctx, someURL+"-other"); err != nil {
+		t.Errorf("Get() of another entry error = %v, want it kept", err)
+	}
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/admin/cache", nil))
+	if _, err := cache.store.Get(ctx, someURL+"-other"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after purging everything error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func get(t *testing.T, client *http.Client, ctx context.Context) *http.Response {
+	t.Helper()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, someURL, nil)
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	return res
+}
+
+func response(statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{"Etag": {`"some-etag"`}, "Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
@@ -0,0 +1,192 @@
+package httpcache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/storage"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMemorySize = 10_000
+	storagePrefix     = ".cache"
+)
+
+var (
+	ErrNotFound = errors.New("cache entry not found")
+)
+
+type (
+	Store interface {
+		Get(ctx context.Context, key string) (Entry, error)
+		Set(ctx context.Context, key string, entry Entry) error
+		Delete(ctx context.Context, key string) error
+		Purge(ctx context.Context) error
+	}
+
+	Entry struct {
+		URL        string      `json:"url"`
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header"`
+		Body       []byte      `json:"body"`
+		Expires    time.Time   `json:"expires"`
+	}
+
+	Memory struct {
+		mu      sync.Mutex
+		size    int
+		order   *list.List
+		entries map[string]*list.Element
+	}
+
+	StorageStore struct {
+		backend storage.Backend
+	}
+
+	memoryEntry struct {
+		key   string
+		entry Entry
+	}
+)
+
+func NewMemory(size int) *Memory {
+	if size <= 0 {
+		size = defaultMemorySize
+	}
+
+	return &Memory{
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) (Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, exists := m.entries[key]
+	if !exists {
+		return Entry{}, ErrNotFound
+	}
+
+	m.order.MoveToFront(element)
+	return element.Value.(*memoryEntry).entry, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, entry Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, exists := m.entries[key]; exists {
+		element.Value.(*memoryEntry).entry = entry
+		m.order.MoveToFront(element)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, entry: entry})
+	for m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, exists := m.entries[key]; exists {
+		m.order.Remove(element)
+		delete(m.entries, key)
+	}
+
+	return nil
+}
+
+func (m *Memory) Purge(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.order.Init()
+	m.entries = map[string]*list.Element{}
+	return nil
+}
+
+func NewStorageStore(backend storage.Backend) *StorageStore {
+	return &StorageStore{
+		backend: backend,
+	}
+}
+
+func (s StorageStore) Get(ctx context.Context, key string) (Entry, error) {
+	reader, _, err := s.backend.Get(ctx, objectKey(key))
+	if errors.Is(err, storage.ErrNotExist) {
+		return Entry{}, ErrNotFound
+	} else if err != nil {
+		return Entry{}, err
+	}
+
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	var entry Entry
+	if err := json.NewDecoder(reader).Decode(&entry); err != nil {
+		return Entry{}, fmt.Errorf("failed to decode cache entry for %s: %w", key, err)
+	}
+
+	return entry, nil
+}
+
+func (s StorageStore) Set(ctx context.Context, key string, entry Entry) error {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return s.backend.Put(ctx, objectKey(key), bytes.NewReader(content), storage.Info{
+		Key:         objectKey(key),
+		Size:        int64(len(content)),
+		ContentType: "application/json",
+	})
+}
+
+func (s StorageStore) Delete(ctx context.Context, key string) error {
+	if err := s.backend.Delete(ctx, objectKey(key)); err != nil && !errors.Is(err, storage.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (s StorageStore) Purge(ctx context.Context) error {
+	objects, err := s.backend.List(ctx, storagePrefix+"/")
+	if err != nil {
+		return err
+	}
+
+	for _, object := range objects {
+		if err := s.backend.Delete(ctx, object.Key); err != nil && !errors.Is(err, storage.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func objectKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	hash := hex.EncodeToString(sum[:])
+	return storagePrefix + "/" + hash[:2] + "/" + hash + ".json"
+}
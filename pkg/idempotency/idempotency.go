@@ -0,0 +1,93 @@
+package idempotency
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMemorySize = 100_000
+)
+
+type (
+	Store interface {
+		Seen(ctx context.Context, key string) (bool, error)
+		Mark(ctx context.Context, key string) error
+	}
+
+	Memory struct {
+		mu      sync.Mutex
+		ttl     time.Duration
+		size    int
+		order   *list.List
+		entries map[string]*list.Element
+		now     func() time.Time
+	}
+
+	memoryEntry struct {
+		key     string
+		expires time.Time
+	}
+)
+
+func NewMemory(size int, ttl time.Duration) *Memory {
+	if size <= 0 {
+		size = defaultMemorySize
+	}
+
+	return &Memory{
+		ttl:     ttl,
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+		now:     time.Now,
+	}
+}
+
+func (m *Memory) Seen(_ context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, exists := m.entries[key]
+	if !exists {
+		return false, nil
+	}
+
+	if m.expired(element) {
+		m.remove(element)
+		return false, nil
+	}
+
+	m.order.MoveToFront(element)
+	return true, nil
+}
+
+func (m *Memory) Mark(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expires := m.now().Add(m.ttl)
+	if element, exists := m.entries[key]; exists {
+		element.Value.(*memoryEntry).expires = expires
+		m.order.MoveToFront(element)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, expires: expires})
+	for m.order.Len() > m.size {
+		m.remove(m.order.Back())
+	}
+
+	return nil
+}
+
+func (m *Memory) expired(element *list.Element) bool {
+	return m.ttl > 0 && !m.now().Before(element.Value.(*memoryEntry).expires)
+}
+
+func (m *Memory) remove(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.entries, element.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,163 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	sqlite, err := NewSQLite(filepath.Join(t.TempDir(), "idempotency.db"), time.Hour)
+	if err != nil {
+		t.Fatalf("NewSQLite() error = %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = sqlite.Close()
+	})
+
+	server := miniredis.RunT(t)
+	server.RequireAuth("secret")
+	redis, err := NewRedis("redis://:secret@"+server.Addr()+"/2", time.Hour)
+	if err != nil {
+		t.Fatalf("NewRedis() error = %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = redis.Close()
+	})
+
+	tests := []struct {
+		name  string
+		store Store
+	}{
+		{name: "Should remember keys in memory", store: NewMemory(10, time.Hour)},
+		{name: "Should remember keys in SQLite", store: sqlite},
+		{name: "Should remember keys in Redis", store: redis},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if seen, err := tt.store.Seen(ctx, "some-key"); err != nil || seen {
+				t.Fatalf("Seen() = %v, %v, want false before Mark()", seen, err)
+			}
+
+			if err := tt.store.Mark(ctx, "some-key"); err != nil {
+				t.Fatalf("Mark() error = %v", err)
+			}
+
+			if err := tt.store.Mark(ctx, "some-key"); err != nil {
+				t.Fatalf("Mark() twice error = %v", err)
+			}
+
+			if seen, err := tt.store.Seen(ctx, "some-key"); err != nil || !seen {
+				t.Errorf("Seen() = %v, %v, want true after Mark()", seen, err)
+			}
+
+			if seen, err := tt.store.Seen(ctx, "another-key"); err != nil || seen {
+				t.Errorf("Seen() = %v, %v, want false for another key", seen, err)
+			}
+		})
+	}
+
+	server.Select(2)
+	if ttl := server.TTL(redisKeyPrefix + "some-key"); ttl != time.Hour {
+		t.Errorf("Redis TTL = %v in database 2, want %v", ttl, time.Hour)
+	}
+}
+
+func TestNewRedis(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawURL  string
+		wantTLS bool
+		wantErr error
+	}{
+		{name: "Should connect in plain text to redis URLs", rawURL: "redis://localhost:6379/0"},
+		{name: "Should connect over TLS to rediss URLs", rawURL: "rediss://localhost:6380/0", wantTLS: true},
+		{name: "Should reject other schemes", rawURL: "http://localhost:6379", wantErr: ErrRedis},
+		{name: "Should reject invalid databases", rawURL: "redis://localhost:6379/db", wantErr: ErrRedis},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewRedis(tt.rawURL, time.Hour)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewRedis() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			defer func() {
+				_ = got.Close()
+			}()
+
+			if hasTLS := got.client.Options().TLSConfig != nil; hasTLS != tt.wantTLS {
+				t.Errorf("NewRedis() TLS = %v, want %v", hasTLS, tt.wantTLS)
+			}
+		})
+	}
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemory(2, time.Minute)
+	store.now = func() time.Time {
+		return now
+	}
+
+	for _, key := range []string{"first", "second"} {
+		_ = store.Mark(ctx, key)
+	}
+
+	_, _ = store.Seen(ctx, "first")
+	_ = store.Mark(ctx, "third")
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		key     string
+		want    bool
+	}{
+		{name: "Should evict the least recently used key", key: "second", want: false},
+		{name: "Should keep recently used keys", key: "first", want: true},
+		{name: "Should keep new keys", key: "third", want: true},
+		{name: "Should expire keys after the TTL", advance: time.Minute, key: "third", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			if got, _ := store.Seen(ctx, tt.key); got != tt.want {
+				t.Errorf("Seen(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLite_Expiry(t *testing.T) {
+	store, err := NewSQLite(filepath.Join(t.TempDir(), "idempotency.db"), time.Minute)
+	if err != nil {
+		t.Fatalf("NewSQLite() error = %v", err)
+	}
+
+	defer func() {
+		_ = store.Close()
+	}()
+
+	now := time.Now()
+	store.now = func() time.Time {
+		return now
+	}
+
+	ctx := context.Background()
+	_ = store.Mark(ctx, "some-key")
+	now = now.Add(time.Minute)
+	if seen, err := store.Seen(ctx, "some-key"); err != nil || seen {
+		t.Errorf("Seen() = %v, %v, want false after the TTL", seen, err)
+	}
+}
@@ -0,0 +1,57 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	redisKeyPrefix = "imgur-fetcher:idempotency:"
+)
+
+var (
+	ErrRedis = errors.New("redis error")
+)
+
+type (
+	Redis struct {
+		client *redis.Client
+		ttl    time.Duration
+	}
+)
+
+func NewRedis(rawURL string, ttl time.Duration) (*Redis, error) {
+	options, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRedis, err)
+	}
+
+	return &Redis{
+		client: redis.NewClient(options),
+		ttl:    ttl,
+	}, nil
+}
+
+func (r *Redis) Seen(ctx context.Context, key string) (bool, error) {
+	count, err := r.client.Exists(ctx, redisKeyPrefix+key).Result()
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrRedis, err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *Redis) Mark(ctx context.Context, key string) error {
+	if err := r.client.Set(ctx, redisKeyPrefix+key, "1", r.ttl).Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrRedis, err)
+	}
+
+	return nil
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
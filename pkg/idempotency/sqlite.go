@@ -0,0 +1,79 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	_ "modernc.org/sqlite"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	pruneEvery = 1000
+)
+
+type (
+	SQLite struct {
+		db    *sql.DB
+		ttl   time.Duration
+		marks atomic.Uint64
+		now   func() time.Time
+	}
+)
+
+func NewSQLite(path string, ttl time.Duration) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS idempotency_keys (
+		key TEXT PRIMARY KEY,
+		expires_at INTEGER NOT NULL
+	)`); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create the idempotency table: %w", err)
+	}
+
+	return &SQLite{
+		db:  db,
+		ttl: ttl,
+		now: time.Now,
+	}, nil
+}
+
+func (s *SQLite) Seen(ctx context.Context, key string) (bool, error) {
+	var expiresAt int64
+	err := s.db.QueryRowContext(ctx, `SELECT expires_at FROM idempotency_keys WHERE key = ?`, key).Scan(&expiresAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return s.ttl <= 0 || s.now().UnixMilli() < expiresAt, nil
+}
+
+func (s *SQLite) Mark(ctx context.Context, key string) error {
+	now := s.now()
+	if _, err := s.db.ExecContext(ctx,
+		`INSERT INTO idempotency_keys (key, expires_at) VALUES (?, ?)
+		ON CONFLICT (key) DO UPDATE SET expires_at = excluded.expires_at`,
+		key, now.Add(s.ttl).UnixMilli(),
+	); err != nil {
+		return err
+	}
+
+	if s.ttl > 0 && s.marks.Add(1)%pruneEvery == 0 {
+		_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= ?`, now.UnixMilli())
+		return err
+	}
+
+	return nil
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,194 @@
+package imgur
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"net/http"
+	"net/url"
+)
+
+const (
+	defaultMaxPages = 50
+)
+
+var (
+	ErrOAuthRequired = errors.New("an OAuth access token is required")
+)
+
+type (
+	AccountOptions struct {
+		OAuthClient *http.Client
+		MaxPages    int
+	}
+
+	GalleryItem struct {
+		ID          string  `json:"id"`
+		Title       string  `json:"title"`
+		Description string  `json:"description"`
+		Link        string  `json:"link"`
+		IsAlbum     bool    `json:"is_album"`
+		Type        string  `json:"type"`
+		MP4         string  `json:"mp4"`
+		HLS         string  `json:"hls"`
+		Size        int64   `json:"size"`
+		MP4Size     int64   `json:"mp4_size"`
+		ImagesCount int     `json:"images_count"`
+		Images      []Media `json:"images"`
+	}
+)
+
+func (c Client) GetAccountSubmissions(ctx context.Context, user string, page int) ([]GalleryItem, error) {
+	var output Response[[]GalleryItem]
+	err := c.doGet(ctx, accountURL(user, "submissions", page), &output)
+	return output.Data, err
+}
+
+func (c Client) GetAccountAlbums(ctx context.Context, user string, page int) ([]Album, error) {
+	var output Response[[]Album]
+	err := c.doGet(ctx, accountURL(user, "albums", page), &output)
+	return output.Data, err
+}
+
+func (c Client) GetAccountImages(ctx context.Context, user string, page int) ([]Media, error) {
+	if c.account.OAuthClient == nil {
+		return nil, ErrOAuthRequired
+	}
+
+	var output Response[[]Media]
+	err := Client{httpClient: c.account.OAuthClient, logger: c.logger}.doGet(ctx, accountURL(user, "images", page), &output)
+	return output.Data, err
+}
+
+func (c Client) GetUserMedia(ctx context.Context, user string, options GalleryOptions) ([]Media, error) {
+	var mediaList []Media
+	seen := map[string]struct{}{}
+	add := func(items ...Media) {
+		for _, m := range items {
+			if _, ok := seen[m.ID]; ok {
+				continue
+			}
+
+			seen[m.ID] = struct{}{}
+			mediaList = append(mediaList, m)
+		}
+	}
+
+	if err := paginate(c.maxPages(options), func(page int) (int, error) {
+		items, err := c.GetAccountSubmissions(ctx, user, page)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, item := range items {
+			images, err := c.galleryItemMedia(ctx, item)
+			if err != nil {
+				return 0, err
+			}
+
+			add(images...)
+		}
+
+		return len(items), nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list submissions of %s: %w", user, err)
+	}
+
+	if err := paginate(c.maxPages(options), func(page int) (int, error) {
+		albums, err := c.GetAccountAlbums(ctx, user, page)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, album := range albums {
+			if len(album.Images) == 0 {
+				if album, err = c.GetAlbum(ctx, album.ID); err != nil {
+					return 0, err
+				}
+			}
+
+			add(album.Images...)
+		}
+
+		return len(albums), nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list albums of %s: %w", user, err)
+	}
+
+	if c.account.OAuthClient == nil {
+		return mediaList, nil
+	}
+
+	if err := paginate(c.maxPages(options), func(page int) (int, error) {
+		images, err := c.GetAccountImages(ctx, user, page)
+		if err != nil {
+			return 0, err
+		}
+
+		add(images...)
+		return len(images), nil
+	}); err != nil {
+		c.logger.WarnContext(ctx, "failed to list account images, keeping the public media", logging.KeyError, err)
+	}
+
+	return mediaList, nil
+}
+
+func (c Client) galleryItemMedia(ctx context.Context, item GalleryItem) ([]Media, error) {
+	if !item.IsAlbum {
+		return []Media{item.Media()}, nil
+	}
+
+	if len(item.Images) > 0 || item.ImagesCount == 0 {
+		return item.Images, nil
+	}
+
+	album, err := c.GetAlbum(ctx, item.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return album.Images, nil
+}
+
+func (i GalleryItem) Media() Media {
+	return Media{
+		ID:          i.ID,
+		Title:       i.Title,
+		Description: i.Description,
+		Link:        i.Link,
+		Type:        i.Type,
+		MP4:         i.MP4,
+		HLS:         i.HLS,
+		Size:        i.Size,
+		MP4Size:     i.MP4Size,
+	}
+}
+
+func (o AccountOptions) maxPages() int {
+	if o.MaxPages > 0 {
+		return o.MaxPages
+	}
+
+	return defaultMaxPages
+}
+
+func paginate(maxPages int, fetch func(page int) (int, error)) error {
+	for page := 0; page < maxPages; page++ {
+		count, err := fetch(page)
+		if err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func accountURL(user, section string, page int) string {
+	return fmt.Sprintf("%s/account/%s/%s/%d", apiPath, url.PathEscape(user), section, page)
+}
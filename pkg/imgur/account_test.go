@@ -0,0 +1,118 @@
+package imgur
+
+import (
+	"context"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/pkg/imgur/testdata"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type (
+	roundTripFunc func(*http.Request) (*http.Response, error)
+)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newAccountClient(responses map[string]string, oauth bool) *Client {
+	var options AccountOptions
+	if oauth {
+		options.OAuthClient = newResponsesClient(responses, "Bearer some-token")
+	}
+
+	return NewClient(newResponsesClient(responses, "Client-ID some-id"), options, logging.Discard())
+}
+
+func newResponsesClient(responses map[string]string, authorization string) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		path := strings.TrimPrefix(req.URL.Path, "/3")
+		if strings.HasSuffix(path, "/images/0") && !strings.HasPrefix(authorization, "Bearer ") {
+			path = "/unauthorized"
+		}
+
+		body, ok := responses[path]
+		statusCode := http.StatusOK
+		if ok && body == "" {
+			statusCode = http.StatusForbidden
+		} else if !ok {
+			body, statusCode = testdata.ImgurEmptyPageResponse, http.StatusOK
+			if !strings.HasPrefix(path, "/account/") && !strings.HasPrefix(path, "/gallery/r/") {
+				statusCode = http.StatusNotFound
+			}
+		}
+
+		return &http.Response{
+			StatusCode: statusCode,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})}
+}
+
+func TestClient_GetUserMedia(t *testing.T) {
+	responses := map[string]string{
+		"/account/someone/submissions/0": testdata.ImgurSubmissionsResponse,
+		"/account/someone/albums/0":      testdata.ImgurAccountAlbumsResponse,
+		"/account/someone/images/0":      testdata.ImgurAccountImagesResponse,
+		"/album/some-album":              testdata.ImgurAlbumResponse,
+		"/account/private/submissions/0": "",
+		"/account/other/submissions/0":   testdata.ImgurSubmissionsResponse,
+		"/account/other/images/0":        "",
+		"/unauthorized":                  "",
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		oauth   bool
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "Should crawl submissions and albums without duplicates",
+			url:  "https://imgur.com/user/someone",
+			want: []string{"some-submission", "some-image-id-1", "some-image-id-2", "other-image"},
+		},
+		{
+			name:  "Should include account images with OAuth",
+			url:   "https://imgur.com/user/someone/posts",
+			oauth: true,
+			want:  []string{"some-submission", "some-image-id-1", "some-image-id-2", "other-image", "hidden-image"},
+		},
+		{
+			name:  "Should keep the public media when account images are forbidden",
+			url:   "https://imgur.com/user/other",
+			oauth: true,
+			want:  []string{"some-submission", "some-image-id-1", "some-image-id-2"},
+		},
+		{
+			name:    "Should fail when the account cannot be listed",
+			url:     "https://imgur.com/user/private",
+			wantErr: status.ErrBadStatus,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newAccountClient(responses, tt.oauth).GetMediaByURL(context.Background(), tt.url, GalleryOptions{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetMediaByURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var ids []string
+			for _, m := range got {
+				ids = append(ids, m.ID)
+			}
+
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("GetMediaByURL() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
@@ -1,28 +1,46 @@
 package imgur
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"github.com/alancesar/imgur-fetcher/pkg/resolver"
 	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"path"
+	"slices"
 	"strings"
 )
 
 const (
+	ProviderName = "imgur"
+
 	apiPath      = "https://api.imgur.com/3"
 	gifImageType = "image/gif"
+	mp4VideoType = "video/mp4"
+	gifvExt      = ".gifv"
+)
+
+var (
+	Hosts = []string{"imgur.com", "imgur.io"}
 )
 
 type (
 	Client struct {
 		httpClient *http.Client
+		account    AccountOptions
+		logger     *slog.Logger
 	}
 
 	Request struct {
-		ID      string
-		IsAlbum bool
+		ID        string
+		IsAlbum   bool
+		User      string
+		Tag       string
+		Subreddit string
 	}
 
 	Response[T any] struct {
@@ -36,6 +54,9 @@ type (
 		Link        string `json:"link"`
 		Type        string `json:"type"`
 		MP4         string `json:"mp4"`
+		HLS         string `json:"hls"`
+		Size        int64  `json:"size"`
+		MP4Size     int64  `json:"mp4_size"`
 	}
 
 	Album struct {
@@ -47,57 +68,122 @@ type (
 	}
 )
 
-func (m Media) HigherQualityURL() string {
-	if m.Type == gifImageType && m.MP4 != "" {
-		return m.MP4
+func (m Media) Resolved() resolver.Media {
+	link, linkType := m.Link, m.Type
+	if path.Ext(link) == gifvExt {
+		link, linkType = strings.TrimSuffix(link, "v"), gifImageType
+	}
+
+	resolved := resolver.Media{
+		ID:          m.ID,
+		Provider:    ProviderName,
+		Title:       m.Title,
+		Description: m.Description,
+		URL:         link,
+		Type:        linkType,
+		Size:        m.Size,
+		Variants: []resolver.Variant{
+			{URL: link, Type: linkType, Size: m.Size},
+		},
+	}
+
+	if m.MP4 != "" && m.MP4 != link {
+		resolved.Variants = append(resolved.Variants, resolver.Variant{URL: m.MP4, Type: mp4VideoType, Size: m.MP4Size})
+	}
+
+	if m.HLS != "" {
+		resolved.Variants = append(resolved.Variants, resolver.Variant{URL: m.HLS, Type: resolver.TypeHLS})
 	}
 
-	return m.Link
+	return resolved
 }
 
-func NewClient(httpClient *http.Client) *Client {
+func NewClient(httpClient *http.Client, account AccountOptions, logger *slog.Logger) *Client {
 	return &Client{
 		httpClient: httpClient,
+		account:    account,
+		logger:     logger,
 	}
 }
 
-func (c Client) GetMediaByURL(rawURL string) ([]Media, error) {
-	request, err := compileURL(rawURL)
+func (c Client) GetMediaByURL(ctx context.Context, rawURL string, options GalleryOptions) ([]Media, error) {
+	request, err := ParseURL(rawURL)
 	if err != nil {
 		return nil, err
 	}
 
+	switch {
+	case request.User != "":
+		ctx = logging.With(ctx, "imgur_user", request.User)
+		c.logger.DebugContext(ctx, "crawling imgur user")
+		return c.GetUserMedia(ctx, request.User, options)
+	case request.Tag != "":
+		ctx = logging.With(ctx, "imgur_tag", request.Tag)
+		c.logger.DebugContext(ctx, "crawling imgur tag")
+		return c.GetTagMedia(ctx, request.Tag, options)
+	case request.Subreddit != "":
+		ctx = logging.With(ctx, "imgur_subreddit", request.Subreddit)
+		c.logger.DebugContext(ctx, "crawling imgur subreddit")
+		return c.GetSubredditMedia(ctx, request.Subreddit, options)
+	}
+
+	ctx = logging.With(ctx, logging.KeyImgurID, request.ID)
+	c.logger.DebugContext(ctx, "resolving imgur url", "is_album", request.IsAlbum)
 	if request.IsAlbum {
-		album, err := c.GetAlbum(request.ID)
+		album, err := c.GetAlbum(ctx, request.ID)
 		if err != nil {
 			return nil, err
 		}
 		return album.Images, nil
 	}
 
-	media, err := c.GetMedia(request.ID)
+	media, err := c.GetMedia(ctx, request.ID)
 	if err != nil {
 		return nil, err
 	}
 	return []Media{media}, nil
 }
 
-func (c Client) GetMedia(imageID string) (Media, error) {
+func (c Client) Resolve(ctx context.Context, rawURL string, o resolver.Options) ([]resolver.Media, error) {
+	options, err := ParseGalleryOptions(o.Sort, o.Window, o.MaxPages, c.account.maxPages())
+	if err != nil {
+		return nil, err
+	}
+
+	mediaList, err := c.GetMediaByURL(ctx, rawURL, options)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]resolver.Media, len(mediaList))
+	for i, m := range mediaList {
+		resolved[i] = m.Resolved()
+	}
+
+	return resolved, nil
+}
+
+func (c Client) GetMedia(ctx context.Context, imageID string) (Media, error) {
 	var output Response[Media]
 	formattedURL := fmt.Sprintf("%s/image/%s", apiPath, imageID)
-	err := c.doGet(formattedURL, &output)
+	err := c.doGet(ctx, formattedURL, &output)
 	return output.Data, err
 }
 
-func (c Client) GetAlbum(albumID string) (Album, error) {
+func (c Client) GetAlbum(ctx context.Context, albumID string) (Album, error) {
 	var output Response[Album]
 	formattedURL := fmt.Sprintf("%s/album/%s", apiPath, albumID)
-	err := c.doGet(formattedURL, &output)
+	err := c.doGet(ctx, formattedURL, &output)
 	return output.Data, err
 }
 
-func (c Client) doGet(url string, output any) error {
-	res, err := c.httpClient.Get(url)
+func (c Client) doGet(ctx context.Context, url string, output any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -106,6 +192,8 @@ func (c Client) doGet(url string, output any) error {
 		_ = res.Body.Close()
 	}()
 
+	c.logger.DebugContext(ctx, "imgur api call", "endpoint", url, "status", res.StatusCode)
+
 	if res.StatusCode == http.StatusNotFound {
 		return fmt.Errorf("%w: %s", status.ErrNotFound, url)
 	} else if res.StatusCode >= http.StatusBadRequest {
@@ -115,12 +203,45 @@ func (c Client) doGet(url string, output any) error {
 	return json.NewDecoder(res.Body).Decode(&output)
 }
 
-func compileURL(rawURL string) (Request, error) {
+func CacheKeys(id string) []string {
+	return []string{
+		fmt.Sprintf("%s/image/%s", apiPath, id),
+		fmt.Sprintf("%s/album/%s", apiPath, id),
+	}
+}
+
+func (r Request) Listing() []string {
+	switch {
+	case r.User != "":
+		return []string{"u", r.User}
+	case r.Tag != "":
+		return []string{"t", r.Tag}
+	case r.Subreddit != "":
+		return []string{"r", r.Subreddit}
+	default:
+		return nil
+	}
+}
+
+func ParseURL(rawURL string) (Request, error) {
 	parsedURL, err := url.Parse(rawURL)
 	if err != nil {
 		return Request{}, err
 	}
 
+	if section, name, ok := listing(parsedURL.Path); ok {
+		switch section {
+		case "post":
+			return Request{ID: name}, nil
+		case "user":
+			return Request{User: name}, nil
+		case "t":
+			return Request{Tag: name}, nil
+		default:
+			return Request{Subreddit: name}, nil
+		}
+	}
+
 	isAlbum := isAlbumURL(parsedURL.Path)
 	elem := strings.Split(parsedURL.Path, ".")
 	id := path.Base(elem[0])
@@ -130,6 +251,35 @@ func compileURL(rawURL string) (Request, error) {
 	}, nil
 }
 
+func listing(path string) (string, string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 2 && segments[0] == "gallery" {
+		segments = segments[1:]
+	}
+
+	if len(segments) < 2 || segments[1] == "" {
+		return "", "", false
+	}
+
+	switch segments[0] {
+	case "t", "r":
+		if len(segments) > 2 && !slices.Contains(Sorts, segments[2]) {
+			return "post", withoutExtension(segments[2]), true
+		}
+
+		return segments[0], segments[1], true
+	case "user":
+		return segments[0], segments[1], true
+	default:
+		return "", "", false
+	}
+}
+
+func withoutExtension(segment string) string {
+	segment, _, _ = strings.Cut(segment, ".")
+	return segment
+}
+
 func isAlbumURL(path string) bool {
 	return strings.Contains(path, "/a/")
 }
@@ -1,7 +1,11 @@
 package imgur
 
 import (
+	"context"
 	"github.com/alancesar/imgur-fetcher/pkg/imgur/testdata"
+	"github.com/alancesar/imgur-fetcher/pkg/resolver"
+	"io"
+	"log/slog"
 	"net/http"
 	"reflect"
 	"testing"
@@ -35,6 +39,7 @@ func TestRestClient_GetMedia(t *testing.T) {
 				Description: "Some image description",
 				Link:        "https://i.imgur.com/some-image.jpg",
 				Type:        "image/jpeg",
+				Size:        524288,
 			},
 			wantErr: false,
 		},
@@ -53,14 +58,17 @@ func TestRestClient_GetMedia(t *testing.T) {
 				Link:        "https://i.imgur.com/some-video.mp4",
 				Type:        "video/mp4",
 				MP4:         "https://i.imgur.com/some-video.mp4",
+				HLS:         "https://i.imgur.com/some-video.m3u8",
+				Size:        2097152,
+				MP4Size:     2097152,
 			},
 			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := NewClient(tt.fields.httpClient)
-			got, err := c.GetMedia(tt.args.imageID)
+			c := NewClient(tt.fields.httpClient, AccountOptions{}, slog.New(slog.NewJSONHandler(io.Discard, nil)))
+			got, err := c.GetMedia(context.Background(), tt.args.imageID)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetMedia() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -121,8 +129,8 @@ func TestImgurClient_GetAlbum(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := NewClient(tt.fields.httpClient)
-			got, err := c.GetAlbum(tt.args.albumID)
+			c := NewClient(tt.fields.httpClient, AccountOptions{}, slog.New(slog.NewJSONHandler(io.Discard, nil)))
+			got, err := c.GetAlbum(context.Background(), tt.args.albumID)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetAlbum() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -134,57 +142,6 @@ func TestImgurClient_GetAlbum(t *testing.T) {
 	}
 }
 
-func TestImgurImage_GetHigherQualityImageURL(t *testing.T) {
-	type fields struct {
-		Type string
-		Link string
-		MP4  string
-	}
-	tests := []struct {
-		name   string
-		fields fields
-		want   string
-	}{
-		{
-			name: "Should return the value from link if it the type is image/jpeg",
-			fields: fields{
-				Type: "image/jpeg",
-				Link: "https://link",
-			},
-			want: "https://link",
-		},
-		{
-			name: "Should return the value from link if it the type is image/gif and mp4 value is empty",
-			fields: fields{
-				Type: "image/gif",
-				Link: "https://link",
-			},
-			want: "https://link",
-		},
-		{
-			name: "Should return the value from mp4 if it is present and the type is image/gif",
-			fields: fields{
-				Type: "image/gif",
-				Link: "https://link",
-				MP4:  "https://mp4",
-			},
-			want: "https://mp4",
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			m := Media{
-				Link: tt.fields.Link,
-				MP4:  tt.fields.MP4,
-				Type: tt.fields.Type,
-			}
-			if got := m.HigherQualityURL(); got != tt.want {
-				t.Errorf("HigherQualityURL() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
 func TestClient_GetMediaByURL(t *testing.T) {
 	type fields struct {
 		httpClient *http.Client
@@ -230,8 +187,9 @@ func TestClient_GetMediaByURL(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			c := Client{
 				httpClient: tt.fields.httpClient,
+				logger:     slog.New(slog.NewJSONHandler(io.Discard, nil)),
 			}
-			got, err := c.GetMediaByURL(tt.args.rawURL)
+			got, err := c.GetMediaByURL(context.Background(), tt.args.rawURL, GalleryOptions{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetMediaByURL() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -242,3 +200,89 @@ func TestClient_GetMediaByURL(t *testing.T) {
 		})
 	}
 }
+
+func TestMedia_Resolved(t *testing.T) {
+	tests := []struct {
+		name  string
+		media Media
+		want  resolver.Media
+	}{
+		{
+			name:  "Should keep images as they are",
+			media: Media{ID: "some-id", Title: "Some title", Type: "image/jpeg", Link: "https://i.imgur.com/some-id.jpg", Size: 10},
+			want: resolver.Media{
+				ID:       "some-id",
+				Provider: ProviderName,
+				Title:    "Some title",
+				URL:      "https://i.imgur.com/some-id.jpg",
+				Type:     "image/jpeg",
+				Size:     10,
+				Variants: []resolver.Variant{{URL: "https://i.imgur.com/some-id.jpg", Type: "image/jpeg", Size: 10}},
+			},
+		},
+		{
+			name:  "Should keep gifs as the original and offer their mp4 as a variant",
+			media: Media{ID: "some-id", Type: "image/gif", Link: "https://i.imgur.com/some-id.gif", MP4: "https://i.imgur.com/some-id.mp4", Size: 10, MP4Size: 5},
+			want: resolver.Media{
+				ID:       "some-id",
+				Provider: ProviderName,
+				URL:      "https://i.imgur.com/some-id.gif",
+				Type:     "image/gif",
+				Size:     10,
+				Variants: []resolver.Variant{
+					{URL: "https://i.imgur.com/some-id.gif", Type: "image/gif", Size: 10},
+					{URL: "https://i.imgur.com/some-id.mp4", Type: "video/mp4", Size: 5},
+				},
+			},
+		},
+		{
+			name:  "Should resolve gifv links into their gif",
+			media: Media{ID: "some-id", Type: "video/mp4", Link: "https://i.imgur.com/some-id.gifv", MP4: "https://i.imgur.com/some-id.mp4", Size: 10, MP4Size: 5},
+			want: resolver.Media{
+				ID:       "some-id",
+				Provider: ProviderName,
+				URL:      "https://i.imgur.com/some-id.gif",
+				Type:     "image/gif",
+				Size:     10,
+				Variants: []resolver.Variant{
+					{URL: "https://i.imgur.com/some-id.gif", Type: "image/gif", Size: 10},
+					{URL: "https://i.imgur.com/some-id.mp4", Type: "video/mp4", Size: 5},
+				},
+			},
+		},
+		{
+			name:  "Should offer the HLS stream of videos as a variant",
+			media: Media{ID: "some-id", Type: "video/mp4", Link: "https://i.imgur.com/some-id.mp4", MP4: "https://i.imgur.com/some-id.mp4", HLS: "https://i.imgur.com/some-id.m3u8", Size: 10, MP4Size: 10},
+			want: resolver.Media{
+				ID:       "some-id",
+				Provider: ProviderName,
+				URL:      "https://i.imgur.com/some-id.mp4",
+				Type:     "video/mp4",
+				Size:     10,
+				Variants: []resolver.Variant{
+					{URL: "https://i.imgur.com/some-id.mp4", Type: "video/mp4", Size: 10},
+					{URL: "https://i.imgur.com/some-id.m3u8", Type: resolver.TypeHLS},
+				},
+			},
+		},
+		{
+			name:  "Should not repeat videos whose link is their mp4",
+			media: Media{ID: "some-id", Type: "video/mp4", Link: "https://i.imgur.com/some-id.mp4", MP4: "https://i.imgur.com/some-id.mp4", Size: 10, MP4Size: 10},
+			want: resolver.Media{
+				ID:       "some-id",
+				Provider: ProviderName,
+				URL:      "https://i.imgur.com/some-id.mp4",
+				Type:     "video/mp4",
+				Size:     10,
+				Variants: []resolver.Variant{{URL: "https://i.imgur.com/some-id.mp4", Type: "video/mp4", Size: 10}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.media.Resolved(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolved() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package imgur
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	SortViral = "viral"
+	SortTime  = "time"
+	SortTop   = "top"
+
+	WindowDay   = "day"
+	WindowWeek  = "week"
+	WindowMonth = "month"
+	WindowYear  = "year"
+	WindowAll   = "all"
+)
+
+var (
+	ErrInvalidGalleryOptions = errors.New("invalid gallery options")
+
+	Sorts   = []string{SortViral, SortTime, SortTop}
+	Windows = []string{WindowDay, WindowWeek, WindowMonth, WindowYear, WindowAll}
+)
+
+type (
+	GalleryOptions struct {
+		Sort     string
+		Window   string
+		MaxPages int
+	}
+
+	Tag struct {
+		Name  string        `json:"name"`
+		Items []GalleryItem `json:"items"`
+	}
+)
+
+func ParseGalleryOptions(sort, window string, maxPages, limit int) (GalleryOptions, error) {
+	options := GalleryOptions{
+		Sort:     strings.ToLower(strings.TrimSpace(sort)),
+		Window:   strings.ToLower(strings.TrimSpace(window)),
+		MaxPages: maxPages,
+	}
+
+	if options.Sort == "" {
+		options.Sort = SortViral
+	}
+
+	if options.Window == "" {
+		options.Window = WindowWeek
+	}
+
+	if !slices.Contains(Sorts, options.Sort) {
+		return GalleryOptions{}, fmt.Errorf("%w: sort %q must be one of %s", ErrInvalidGalleryOptions, options.Sort, strings.Join(Sorts, ", "))
+	}
+
+	if !slices.Contains(Windows, options.Window) {
+		return GalleryOptions{}, fmt.Errorf("%w: window %q must be one of %s", ErrInvalidGalleryOptions, options.Window, strings.Join(Windows, ", "))
+	}
+
+	if options.MaxPages < 0 {
+		return GalleryOptions{}, fmt.Errorf("%w: max pages must not be negative", ErrInvalidGalleryOptions)
+	}
+
+	if limit > 0 && options.MaxPages > limit {
+		options.MaxPages = limit
+	}
+
+	return options, nil
+}
+
+func (c Client) GetTagGallery(ctx context.Context, tag string, options GalleryOptions, page int) ([]GalleryItem, error) {
+	var output Response[Tag]
+	err := c.doGet(ctx, galleryURL("t", tag, options, page), &output)
+	return output.Data.Items, err
+}
+
+func (c Client) GetSubredditGallery(ctx context.Context, subreddit string, options GalleryOptions, page int) ([]GalleryItem, error) {
+	var output Response[[]GalleryItem]
+	err := c.doGet(ctx, galleryURL("r", subreddit, options, page), &output)
+	return output.Data, err
+}
+
+func (c Client) GetTagMedia(ctx context.Context, tag string, options GalleryOptions) ([]Media, error) {
+	mediaList, err := c.crawlGallery(ctx, options, func(options GalleryOptions, page int) ([]GalleryItem, error) {
+		return c.GetTagGallery(ctx, tag, options, page)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tag %s: %w", tag, err)
+	}
+
+	return mediaList, nil
+}
+
+func (c Client) GetSubredditMedia(ctx context.Context, subreddit string, options GalleryOptions) ([]Media, error) {
+	mediaList, err := c.crawlGallery(ctx, options, func(options GalleryOptions, page int) ([]GalleryItem, error) {
+		return c.GetSubredditGallery(ctx, subreddit, options, page)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subreddit %s: %w", subreddit, err)
+	}
+
+	return mediaList, nil
+}
+
+func (c Client) crawlGallery(ctx context.Context, options GalleryOptions, list func(options GalleryOptions, page int) ([]GalleryItem, error)) ([]Media, error) {
+	var mediaList []Media
+	seen := map[string]struct{}{}
+	err := paginate(c.maxPages(options), func(page int) (int, error) {
+		items, err := list(options, page)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, item := range items {
+			images, err := c.galleryItemMedia(ctx, item)
+			if err != nil {
+				return 0, err
+			}
+
+			for _, m := range images {
+				if _, ok := seen[m.ID]; !ok {
+					seen[m.ID] = struct{}{}
+					mediaList = append(mediaList, m)
+				}
+			}
+		}
+
+		return len(items), nil
+	})
+
+	return mediaList, err
+}
+
+func (c Client) maxPages(options GalleryOptions) int {
+	if options.MaxPages > 0 {
+		return options.MaxPages
+	}
+
+	return c.account.maxPages()
+}
+
+func (o GalleryOptions) orDefaults() GalleryOptions {
+	if o.Sort == "" {
+		o.Sort = SortViral
+	}
+
+	if o.Window == "" {
+		o.Window = WindowWeek
+	}
+
+	return o
+}
+
+func galleryURL(section, name string, options GalleryOptions, page int) string {
+	options = options.orDefaults()
+	return fmt.Sprintf("%s/gallery/%s/%s/%s/%s/%d", apiPath, section, url.PathEscape(name), options.Sort, options.Window, page)
+}
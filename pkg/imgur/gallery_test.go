@@ -0,0 +1,108 @@
+package imgur
+
+import (
+	"context"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/pkg/imgur/testdata"
+	"reflect"
+	"testing"
+)
+
+func TestClient_GetMediaByURL_Gallery(t *testing.T) {
+	responses := map[string]string{
+		"/gallery/t/funny/viral/week/0": testdata.ImgurTagResponse,
+		"/gallery/t/funny/viral/week/1": testdata.ImgurEmptyTagResponse,
+		"/gallery/t/funny/top/all/0":    testdata.ImgurTagResponse,
+		"/gallery/t/funny/top/all/1":    testdata.ImgurTagResponse,
+		"/gallery/r/aww/time/day/0":     testdata.ImgurSubredditResponse,
+		"/album/some-album":             testdata.ImgurAlbumResponse,
+		"/image/AbC123":                 testdata.ImgurImageResponse,
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		options GalleryOptions
+		want    []string
+	}{
+		{
+			name: "Should expand tag posts into their album and image media",
+			url:  "https://imgur.com/t/funny",
+			want: []string{"some-image-id-1", "some-image-id-2", "some-submission"},
+		},
+		{
+			name:    "Should follow the sort, window and page limit",
+			url:     "https://imgur.com/gallery/t/funny",
+			options: GalleryOptions{Sort: SortTop, Window: WindowAll, MaxPages: 1},
+			want:    []string{"some-image-id-1", "some-image-id-2", "some-submission"},
+		},
+		{
+			name:    "Should list subreddit galleries",
+			url:     "https://imgur.com/r/aww/time",
+			options: GalleryOptions{Sort: SortTime, Window: WindowDay},
+			want:    []string{"subreddit-image"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newAccountClient(responses, false).GetMediaByURL(context.Background(), tt.url, tt.options)
+			if err != nil {
+				t.Fatalf("GetMediaByURL() error = %v", err)
+			}
+
+			var ids []string
+			for _, m := range got {
+				ids = append(ids, m.ID)
+			}
+
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("GetMediaByURL() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want Request
+	}{
+		{url: "https://imgur.com/a/some-album", want: Request{ID: "some-album", IsAlbum: true}},
+		{url: "https://i.imgur.com/AbC123.jpg", want: Request{ID: "AbC123"}},
+		{url: "https://imgur.com/user/someone", want: Request{User: "someone"}},
+		{url: "https://imgur.com/t/funny/top", want: Request{Tag: "funny"}},
+		{url: "https://imgur.com/gallery/r/aww", want: Request{Subreddit: "aww"}},
+		{url: "https://imgur.com/r/aww/AbC123", want: Request{ID: "AbC123"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := ParseURL(tt.url)
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseURL() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGalleryOptions(t *testing.T) {
+	got, err := ParseGalleryOptions("", "", 0, 0)
+	if err != nil || got != (GalleryOptions{Sort: SortViral, Window: WindowWeek}) {
+		t.Errorf("ParseGalleryOptions() = %+v, %v, want the defaults", got, err)
+	}
+
+	if _, err := ParseGalleryOptions("rising", "", 0, 0); !errors.Is(err, ErrInvalidGalleryOptions) {
+		t.Errorf("ParseGalleryOptions() error = %v, want %v", err, ErrInvalidGalleryOptions)
+	}
+
+	if _, err := ParseGalleryOptions(SortTop, "decade", 0, 0); !errors.Is(err, ErrInvalidGalleryOptions) {
+		t.Errorf("ParseGalleryOptions() error = %v, want %v", err, ErrInvalidGalleryOptions)
+	}
+
+	if _, err := ParseGalleryOptions("", "", -1, 2); !errors.Is(err, ErrInvalidGalleryOptions) {
+		t.Errorf("ParseGalleryOptions() error = %v, want %v", err, ErrInvalidGalleryOptions)
+	}
+
+	if got, err := ParseGalleryOptions("", "", 5, 2); err != nil || got.MaxPages != 2 {
+		t.Errorf("ParseGalleryOptions() = %+v, %v, want max pages lowered to 2", got, err)
+	}
+}
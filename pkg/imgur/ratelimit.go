@@ -0,0 +1,135 @@
+package imgur
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	headerClientLimit     = "X-RateLimit-ClientLimit"
+	headerClientRemaining = "X-RateLimit-ClientRemaining"
+	headerUserLimit       = "X-RateLimit-UserLimit"
+	headerUserRemaining   = "X-RateLimit-UserRemaining"
+	headerUserReset       = "X-RateLimit-UserReset"
+)
+
+var (
+	ErrRateLimitExhausted = errors.New("rate limit exhausted")
+)
+
+type (
+	RateLimit struct {
+		ClientLimit     int
+		ClientRemaining int
+		UserLimit       int
+		UserRemaining   int
+		UserReset       time.Time
+	}
+
+	Monitor struct {
+		window     time.Duration
+		mu         sync.RWMutex
+		lastErr    error
+		lastStatus int
+		lastCall   time.Time
+		rateLimit  RateLimit
+		hasLimit   bool
+	}
+)
+
+func ParseRateLimit(header http.Header) (RateLimit, bool) {
+	if header.Get(headerClientRemaining) == "" && header.Get(headerUserRemaining) == "" {
+		return RateLimit{}, false
+	}
+
+	limit := RateLimit{
+		ClientLimit:     atoi(header.Get(headerClientLimit)),
+		ClientRemaining: atoi(header.Get(headerClientRemaining)),
+		UserLimit:       atoi(header.Get(headerUserLimit)),
+		UserRemaining:   atoi(header.Get(headerUserRemaining)),
+	}
+
+	if reset := atoi(header.Get(headerUserReset)); reset > 0 {
+		limit.UserReset = time.Unix(int64(reset), 0)
+	}
+
+	return limit, true
+}
+
+func (r RateLimit) Exhausted(now time.Time) bool {
+	if r.ClientLimit > 0 && r.ClientRemaining <= 0 {
+		return true
+	}
+
+	return r.UserLimit > 0 && r.UserRemaining <= 0 && now.Before(r.UserReset)
+}
+
+func NewMonitor(window time.Duration) *Monitor {
+	return &Monitor{
+		window: window,
+	}
+}
+
+func (m *Monitor) Observe(_ *http.Request, res *http.Response, err error, _ time.Duration) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastCall = time.Now()
+	m.lastErr = err
+	m.lastStatus = 0
+	if res == nil {
+		return
+	}
+
+	m.lastStatus = res.StatusCode
+	if limit, ok := ParseRateLimit(res.Header); ok {
+		m.rateLimit = limit
+		m.hasLimit = true
+	}
+}
+
+func (m *Monitor) RateLimit() (RateLimit, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.rateLimit, m.hasLimit
+}
+
+func (m *Monitor) Check(_ context.Context) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	if m.lastCall.IsZero() || now.Sub(m.lastCall) > m.window {
+		return nil
+	}
+
+	if m.hasLimit && m.rateLimit.Exhausted(now) {
+		return ErrRateLimitExhausted
+	}
+
+	if m.lastErr != nil {
+		return fmt.Errorf("last call failed: %w", m.lastErr)
+	}
+
+	if m.lastStatus == http.StatusTooManyRequests || m.lastStatus >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: last call returned %d", status.ErrBadStatus, m.lastStatus)
+	}
+
+	return nil
+}
+
+func atoi(value string) int {
+	i, _ := strconv.Atoi(value)
+	return i
+}
@@ -0,0 +1,59 @@
+package imgur
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMonitor_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		res     *http.Response
+		err     error
+		wantErr bool
+	}{
+		{
+			name:    "Should be healthy after a successful call",
+			res:     &http.Response{StatusCode: http.StatusOK, Header: http.Header{}},
+			wantErr: false,
+		},
+		{
+			name:    "Should be unhealthy after a transport error",
+			err:     errors.New("connection refused"),
+			wantErr: true,
+		},
+		{
+			name:    "Should be unhealthy after a server error",
+			res:     &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{}},
+			wantErr: true,
+		},
+		{
+			name:    "Should be unhealthy after being rate limited",
+			res:     &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}},
+			wantErr: true,
+		},
+		{
+			name:    "Should ignore calls canceled by the caller",
+			err:     fmt.Errorf("get: %w", context.Canceled),
+			wantErr: false,
+		},
+		{
+			name:    "Should ignore calls whose deadline expired",
+			err:     fmt.Errorf("get: %w", context.DeadlineExceeded),
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMonitor(time.Minute)
+			m.Observe(nil, tt.res, tt.err, 0)
+			if err := m.Check(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
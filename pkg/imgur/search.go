@@ -0,0 +1,136 @@
+package imgur
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	SearchTypeJPG    = "jpg"
+	SearchTypePNG    = "png"
+	SearchTypeGIF    = "gif"
+	SearchTypeAnigif = "anigif"
+	SearchTypeAlbum  = "album"
+
+	SearchSizeSmall  = "small"
+	SearchSizeMedium = "med"
+	SearchSizeBig    = "big"
+	SearchSizeLarge  = "lrg"
+	SearchSizeHuge   = "huge"
+)
+
+var (
+	ErrInvalidSearch = errors.New("invalid search")
+
+	SearchTypes = []string{SearchTypeJPG, SearchTypePNG, SearchTypeGIF, SearchTypeAnigif, SearchTypeAlbum}
+	SearchSizes = []string{SearchSizeSmall, SearchSizeMedium, SearchSizeBig, SearchSizeLarge, SearchSizeHuge}
+)
+
+type (
+	SearchQuery struct {
+		Q       string
+		All     string
+		Any     string
+		Exactly string
+		Not     string
+		Type    string
+		SizePx  string
+	}
+)
+
+func ParseSearchQuery(values url.Values) (SearchQuery, error) {
+	query := SearchQuery{
+		Q:       strings.TrimSpace(values.Get("q")),
+		All:     strings.TrimSpace(values.Get("q_all")),
+		Any:     strings.TrimSpace(values.Get("q_any")),
+		Exactly: strings.TrimSpace(values.Get("q_exactly")),
+		Not:     strings.TrimSpace(values.Get("q_not")),
+		Type:    strings.ToLower(strings.TrimSpace(values.Get("q_type"))),
+		SizePx:  strings.ToLower(strings.TrimSpace(values.Get("q_size_px"))),
+	}
+
+	return query, query.Validate()
+}
+
+func (q SearchQuery) Validate() error {
+	if q.Q == "" && q.All == "" && q.Any == "" && q.Exactly == "" {
+		return fmt.Errorf("%w: one of q, q_all, q_any or q_exactly is required", ErrInvalidSearch)
+	}
+
+	if q.Type != "" && !slices.Contains(SearchTypes, q.Type) {
+		return fmt.Errorf("%w: q_type %q must be one of %s", ErrInvalidSearch, q.Type, strings.Join(SearchTypes, ", "))
+	}
+
+	if q.SizePx != "" && !slices.Contains(SearchSizes, q.SizePx) {
+		return fmt.Errorf("%w: q_size_px %q must be one of %s", ErrInvalidSearch, q.SizePx, strings.Join(SearchSizes, ", "))
+	}
+
+	return nil
+}
+
+func (q SearchQuery) Values() url.Values {
+	values := url.Values{}
+	for key, value := range map[string]string{
+		"q":         q.Q,
+		"q_all":     q.All,
+		"q_any":     q.Any,
+		"q_exactly": q.Exactly,
+		"q_not":     q.Not,
+		"q_type":    q.Type,
+		"q_size_px": q.SizePx,
+	} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+
+	return values
+}
+
+func (c Client) Search(ctx context.Context, query SearchQuery, options GalleryOptions, page int) ([]GalleryItem, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	var output Response[[]GalleryItem]
+	err := c.doGet(ctx, searchURL(query, options, page), &output)
+	return output.Data, err
+}
+
+func (c Client) SearchItems(ctx context.Context, query SearchQuery, options GalleryOptions, page int) ([]GalleryItem, error) {
+	var hits []GalleryItem
+	seen := map[string]struct{}{}
+	err := paginate(c.maxPages(options), func(offset int) (int, error) {
+		items, err := c.Search(ctx, query, options, page+offset)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, item := range items {
+			if _, ok := seen[item.ID]; !ok {
+				seen[item.ID] = struct{}{}
+				hits = append(hits, item)
+			}
+		}
+
+		return len(items), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	return hits, nil
+}
+
+func (c Client) GalleryItemMedia(ctx context.Context, item GalleryItem) ([]Media, error) {
+	return c.galleryItemMedia(ctx, item)
+}
+
+func searchURL(query SearchQuery, options GalleryOptions, page int) string {
+	options = options.orDefaults()
+	return fmt.Sprintf("%s/gallery/search/%s/%s/%d?%s", apiPath, options.Sort, options.Window, page, query.Values().Encode())
+}
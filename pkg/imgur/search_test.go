@@ -0,0 +1,127 @@
+package imgur
+
+import (
+	"context"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/pkg/imgur/testdata"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClient_SearchItems(t *testing.T) {
+	var requested []string
+	httpClient := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requested = append(requested, strings.TrimPrefix(req.URL.Path, "/3")+"?"+req.URL.RawQuery)
+		body := testdata.ImgurEmptyPageResponse
+		if strings.HasSuffix(req.URL.Path, "/2") || strings.HasSuffix(req.URL.Path, "/3") {
+			body = testdata.ImgurSearchResponse
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})}
+
+	tests := []struct {
+		name    string
+		query   SearchQuery
+		options GalleryOptions
+		page    int
+		want    []string
+		wantURL []string
+	}{
+		{
+			name:    "Should send the advanced query and deduplicate hits across pages",
+			query:   SearchQuery{All: "cute cats", Not: "dogs", Type: SearchTypeAnigif, SizePx: SearchSizeLarge},
+			options: GalleryOptions{Sort: SortTop, Window: WindowAll, MaxPages: 3},
+			page:    2,
+			want:    []string{"some-album", "search-image"},
+			wantURL: []string{
+				"/gallery/search/top/all/2?q_all=cute+cats&q_not=dogs&q_size_px=lrg&q_type=anigif",
+				"/gallery/search/top/all/3?q_all=cute+cats&q_not=dogs&q_size_px=lrg&q_type=anigif",
+				"/gallery/search/top/all/4?q_all=cute+cats&q_not=dogs&q_size_px=lrg&q_type=anigif",
+			},
+		},
+		{
+			name:    "Should default to the viral weekly gallery",
+			query:   SearchQuery{Exactly: "some phrase"},
+			options: GalleryOptions{MaxPages: 1},
+			wantURL: []string{"/gallery/search/viral/week/0?q_exactly=some+phrase"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requested = nil
+			c := NewClient(httpClient, AccountOptions{}, logging.Discard())
+			got, err := c.SearchItems(context.Background(), tt.query, tt.options, tt.page)
+			if err != nil {
+				t.Fatalf("SearchItems() error = %v", err)
+			}
+
+			var ids []string
+			for _, item := range got {
+				ids = append(ids, item.ID)
+			}
+
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("SearchItems() = %v, want %v", ids, tt.want)
+			}
+
+			if !reflect.DeepEqual(requested, tt.wantURL) {
+				t.Errorf("SearchItems() requested = %v, want %v", requested, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  url.Values
+		want    SearchQuery
+		wantErr bool
+	}{
+		{
+			name:   "Should parse every advanced field",
+			values: url.Values{"q_any": {"cats dogs"}, "q_type": {"GIF"}, "q_size_px": {"med"}},
+			want:   SearchQuery{Any: "cats dogs", Type: SearchTypeGIF, SizePx: SearchSizeMedium},
+		},
+		{
+			name:    "Should require a search term",
+			values:  url.Values{"q_not": {"dogs"}},
+			want:    SearchQuery{Not: "dogs"},
+			wantErr: true,
+		},
+		{
+			name:    "Should reject unknown types",
+			values:  url.Values{"q": {"cats"}, "q_type": {"bmp"}},
+			want:    SearchQuery{Q: "cats", Type: "bmp"},
+			wantErr: true,
+		},
+		{
+			name:    "Should reject unknown sizes",
+			values:  url.Values{"q": {"cats"}, "q_size_px": {"tiny"}},
+			want:    SearchQuery{Q: "cats", SizePx: "tiny"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSearchQuery(tt.values)
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInvalidSearch)) {
+				t.Errorf("ParseSearchQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSearchQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
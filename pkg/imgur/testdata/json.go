@@ -9,6 +9,7 @@ const ImgurImageResponse = `
     "type": "image\/jpeg",
     "width": 1080,
     "height": 1920,
+    "size": 524288,
     "link": "https:\/\/i.imgur.com\/some-image.jpg"
   },
   "success": true,
@@ -25,8 +26,10 @@ const ImgurVideoResponse = `
     "type": "video\/mp4",
     "width": 1920,
     "height": 1080,
+    "size": 2097152,
     "link": "https:\/\/i.imgur.com\/some-video.mp4",
     "mp4": "https:\/\/i.imgur.com\/some-video.mp4",
+    "mp4_size": 2097152,
     "gifv": "https:\/\/i.imgur.com\/some-video.gifv",
     "hls": "https:\/\/i.imgur.com\/some-video.m3u8"
   },
@@ -68,3 +71,164 @@ const ImgurAlbumResponse = `
   "status": 200
 }
 `
+
+const ImgurEmptyPageResponse = `
+{
+  "data": [],
+  "success": true,
+  "status": 200
+}
+`
+
+const ImgurSubmissionsResponse = `
+{
+  "data": [
+    {
+      "id": "some-submission",
+      "title": "Some submission title",
+      "type": "image\/gif",
+      "link": "https:\/\/i.imgur.com\/some-submission.gif",
+      "mp4": "https:\/\/i.imgur.com\/some-submission.mp4",
+      "size": 20,
+      "mp4_size": 10,
+      "is_album": false
+    },
+    {
+      "id": "some-album",
+      "title": "Some album title",
+      "link": "https:\/\/imgur.com\/a\/some-album",
+      "is_album": true,
+      "images_count": 2
+    }
+  ],
+  "success": true,
+  "status": 200
+}
+`
+
+const ImgurAccountAlbumsResponse = `
+{
+  "data": [
+    {
+      "id": "some-album",
+      "title": "Some album title",
+      "link": "https:\/\/imgur.com\/a\/some-album"
+    },
+    {
+      "id": "other-album",
+      "title": "Other album title",
+      "link": "https:\/\/imgur.com\/a\/other-album",
+      "images": [
+        {
+          "id": "other-image",
+          "type": "image\/png",
+          "link": "https:\/\/i.imgur.com\/other-image.png"
+        }
+      ]
+    }
+  ],
+  "success": true,
+  "status": 200
+}
+`
+
+const ImgurAccountImagesResponse = `
+{
+  "data": [
+    {
+      "id": "some-image-id-1",
+      "title": "Some image #1 title",
+      "description": "Some image #1 description",
+      "type": "image\/jpeg",
+      "link": "https:\/\/i.imgur.com\/some-image-1.jpg"
+    },
+    {
+      "id": "hidden-image",
+      "type": "image\/jpeg",
+      "link": "https:\/\/i.imgur.com\/hidden-image.jpg"
+    }
+  ],
+  "success": true,
+  "status": 200
+}
+`
+
+const ImgurTagResponse = `
+{
+  "data": {
+    "name": "funny",
+    "display_name": "Funny",
+    "total_items": 2,
+    "items": [
+      {
+        "id": "some-album",
+        "title": "Some album title",
+        "link": "https:\/\/imgur.com\/a\/some-album",
+        "is_album": true,
+        "images_count": 2
+      },
+      {
+        "id": "some-submission",
+        "title": "Some submission title",
+        "type": "image\/gif",
+        "link": "https:\/\/i.imgur.com\/some-submission.gif",
+        "mp4": "https:\/\/i.imgur.com\/some-submission.mp4",
+        "is_album": false
+      }
+    ]
+  },
+  "success": true,
+  "status": 200
+}
+`
+
+const ImgurEmptyTagResponse = `
+{
+  "data": {
+    "name": "funny",
+    "items": []
+  },
+  "success": true,
+  "status": 200
+}
+`
+
+const ImgurSubredditResponse = `
+{
+  "data": [
+    {
+      "id": "subreddit-image",
+      "title": "Some subreddit image",
+      "type": "image\/jpeg",
+      "link": "https:\/\/i.imgur.com\/subreddit-image.jpg",
+      "is_album": false,
+      "section": "aww"
+    }
+  ],
+  "success": true,
+  "status": 200
+}
+`
+
+const ImgurSearchResponse = `
+{
+  "data": [
+    {
+      "id": "some-album",
+      "title": "Some album title",
+      "link": "https:\/\/imgur.com\/a\/some-album",
+      "is_album": true,
+      "images_count": 2
+    },
+    {
+      "id": "search-image",
+      "title": "Some search image",
+      "type": "image\/png",
+      "link": "https:\/\/i.imgur.com\/search-image.png",
+      "is_album": false
+    }
+  ],
+  "success": true,
+  "status": 200
+}
+`
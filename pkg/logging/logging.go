@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/tracing"
+	"github.com/go-chi/chi/v5/middleware"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	KeyError       = "error"
+	KeyRequestID   = "request_id"
+	KeyMessageID   = "message_id"
+	KeyDeliveryTag = "delivery_tag"
+	KeyTraceID     = "trace_id"
+	KeyURL         = "url"
+	KeyImgurID     = "imgur_id"
+)
+
+type (
+	contextKey struct{}
+
+	ContextHandler struct {
+		next slog.Handler
+	}
+)
+
+func New(w io.Writer, level string) (*slog.Logger, error) {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: parsed,
+	})
+
+	return slog.New(NewContextHandler(handler)), nil
+}
+
+func Discard() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(io.Discard, nil))
+}
+
+func ParseLevel(level string) (slog.Level, error) {
+	var parsed slog.Level
+	if level == "" {
+		return slog.LevelInfo, nil
+	}
+
+	if err := parsed.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		return parsed, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	return parsed, nil
+}
+
+func With(ctx context.Context, args ...any) context.Context {
+	attrs := append(attrsFromContext(ctx), argsToAttrs(args)...)
+	return context.WithValue(ctx, contextKey{}, attrs)
+}
+
+func NewContextHandler(next slog.Handler) *ContextHandler {
+	return &ContextHandler{
+		next: next,
+	}
+}
+
+func (h ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := middleware.GetReqID(ctx); requestID != "" {
+		record.AddAttrs(slog.String(KeyRequestID, requestID))
+	}
+
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		record.AddAttrs(slog.String(KeyTraceID, traceID))
+	}
+
+	record.AddAttrs(attrsFromContext(ctx)...)
+	return h.next.Handle(ctx, record)
+}
+
+func (h ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return NewContextHandler(h.next.WithAttrs(attrs))
+}
+
+func (h ContextHandler) WithGroup(name string) slog.Handler {
+	return NewContextHandler(h.next.WithGroup(name))
+}
+
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	return append([]slog.Attr(nil), attrs...)
+}
+
+func argsToAttrs(args []any) []slog.Attr {
+	var record slog.Record
+	record.Add(args...)
+
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+
+	return attrs
+}
@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5/middleware"
+	"reflect"
+	"testing"
+)
+
+func TestContextHandler_Handle(t *testing.T) {
+	var buffer bytes.Buffer
+	logger, err := New(&buffer, "debug")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "some-request-id")
+	ctx = With(ctx, KeyURL, "https://imgur.com/a/some-album")
+	ctx = With(ctx, KeyImgurID, "some-album")
+	logger.DebugContext(ctx, "some message", KeyError, "some error")
+
+	var got map[string]any
+	if err := json.Unmarshal(buffer.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode log line: %v", err)
+	}
+
+	delete(got, "time")
+	want := map[string]any{
+		"level":      "DEBUG",
+		"msg":        "some message",
+		KeyError:     "some error",
+		KeyRequestID: "some-request-id",
+		KeyURL:       "https://imgur.com/a/some-album",
+		KeyImgurID:   "some-album",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Handle() got = %v, want %v", got, want)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("ParseLevel() error = %v, wantErr %v", err, true)
+	}
+}
@@ -1,8 +1,70 @@
 package media
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type (
 	Media struct {
 		URL    string   `json:"url"`
 		Parent []string `json:"parent"`
+		Size   int64    `json:"size,omitempty"`
+		ID     string   `json:"id,omitempty"`
+	}
+
+	Post struct {
+		Author   string   `json:"author"`
+		URL      string   `json:"url"`
+		Parent   []string `json:"parent"`
+		Variant  string   `json:"variant,omitempty"`
+		MaxSize  int64    `json:"max_size,omitempty"`
+		Sort     string   `json:"sort,omitempty"`
+		Window   string   `json:"window,omitempty"`
+		MaxPages int      `json:"max_pages,omitempty"`
+	}
+
+	Completed struct {
+		ID          string    `json:"id,omitempty"`
+		URL         string    `json:"url"`
+		Parent      []string  `json:"parent"`
+		Path        string    `json:"path"`
+		Size        int64     `json:"size"`
+		ContentType string    `json:"content_type"`
+		SHA256      string    `json:"sha256,omitempty"`
+		CompletedAt time.Time `json:"completed_at"`
 	}
 )
+
+func (m Media) MessageID() string {
+	sum := sha256.Sum256([]byte(m.ID + "\x00" + m.URL + "\x00" + strings.Join(m.Parent, "/")))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (p Post) MessageID() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		p.URL,
+		strings.Join(p.Media().Parent, "/"),
+		p.Variant,
+		strconv.FormatInt(p.MaxSize, 10),
+		p.Sort,
+		p.Window,
+		strconv.Itoa(p.MaxPages),
+	}, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (p Post) Media() Media {
+	parent := p.Parent
+	if len(parent) == 0 {
+		parent = []string{"u", p.Author}
+	}
+
+	return Media{
+		URL:    p.URL,
+		Parent: parent,
+	}
+}
@@ -0,0 +1,78 @@
+package media
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMedia_MessageID(t *testing.T) {
+	base := Media{URL: "https://i.imgur.com/some-id.mp4", Parent: []string{"u", "someone"}, ID: "some-id"}
+	tests := []struct {
+		name  string
+		other Media
+		same  bool
+	}{
+		{
+			name:  "Should match the same media",
+			other: base,
+			same:  true,
+		},
+		{
+			name:  "Should ignore the expected size",
+			other: Media{URL: base.URL, Parent: base.Parent, ID: base.ID, Size: 10},
+			same:  true,
+		},
+		{
+			name:  "Should tell variants of the same ID apart",
+			other: Media{URL: "https://i.imgur.com/some-id.gif", Parent: base.Parent, ID: base.ID},
+		},
+		{
+			name:  "Should tell parents apart",
+			other: Media{URL: base.URL, Parent: []string{"u", "another"}, ID: base.ID},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base.MessageID() == tt.other.MessageID(); got != tt.same {
+				t.Errorf("MessageID() equal = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestPost_Media(t *testing.T) {
+	got := Post{Author: "someone", URL: "https://imgur.com/some-id"}.Media()
+	if !reflect.DeepEqual(got.Parent, []string{"u", "someone"}) {
+		t.Errorf("Media() parent = %v, want %v", got.Parent, []string{"u", "someone"})
+	}
+}
+
+func TestPost_MessageID(t *testing.T) {
+	base := Post{Author: "someone", URL: "https://imgur.com/t/funny", Sort: "top"}
+	tests := []struct {
+		name  string
+		other Post
+		same  bool
+	}{
+		{
+			name:  "Should match the author parent when the parent is explicit",
+			other: Post{URL: base.URL, Parent: []string{"u", "someone"}, Sort: base.Sort},
+			same:  true,
+		},
+		{
+			name:  "Should tell gallery options apart",
+			other: Post{Author: base.Author, URL: base.URL, Sort: "time"},
+		},
+		{
+			name:  "Should tell variant policies apart",
+			other: Post{Author: base.Author, URL: base.URL, Sort: base.Sort, Variant: "original"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base.MessageID() == tt.other.MessageID(); got != tt.same {
+				t.Errorf("MessageID() equal = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package metrics
+
+import (
+	"github.com/alancesar/imgur-fetcher/pkg/imgur"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	namespace = "imgur_fetcher"
+
+	OutcomeConsumed  = "consumed"
+	OutcomeAcked     = "acked"
+	OutcomeNacked    = "nacked"
+	OutcomeDiscarded = "discarded"
+
+	otherLabel = "other"
+)
+
+var (
+	hosts = map[string]struct{}{
+		"api.imgur.com":  {},
+		"www.reddit.com": {},
+		"reddit.com":     {},
+		"old.reddit.com": {},
+	}
+
+	imgurEndpoints = []string{"image", "album", "gallery", "account"}
+)
+
+type (
+	Metrics struct {
+		registry        *prometheus.Registry
+		serverRequests  *prometheus.CounterVec
+		serverDuration  *prometheus.HistogramVec
+		clientRequests  *prometheus.CounterVec
+		clientDuration  *prometheus.HistogramVec
+		rateLimit       *prometheus.GaugeVec
+		messages        *prometheus.CounterVec
+		albumItems      prometheus.Histogram
+		publishDuration *prometheus.HistogramVec
+	}
+)
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		serverRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http_server",
+			Name:      "requests_total",
+			Help:      "HTTP requests handled by the API, by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		serverDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http_server",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of HTTP requests handled by the API.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		clientRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "requests_total",
+			Help:      "Outgoing HTTP requests, by host, endpoint and status code.",
+		}, []string{"host", "endpoint", "code"}),
+		clientDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of outgoing HTTP requests.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"host", "endpoint"}),
+		rateLimit: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "imgur",
+			Name:      "rate_limit",
+			Help:      "Imgur rate limit budget as reported by the last response headers.",
+		}, []string{"kind"}),
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "worker",
+			Name:      "messages_total",
+			Help:      "Messages handled by the worker, by queue and outcome.",
+		}, []string{"queue", "outcome"}),
+		albumItems: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "worker",
+			Name:      "album_items",
+			Help:      "Number of media items resolved per message.",
+			Buckets:   []float64{1, 2, 5, 10, 25, 50, 100, 250, 500},
+		}),
+		publishDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "amqp",
+			Name:      "publish_duration_seconds",
+			Help:      "Latency of AMQP publishes, by exchange, routing key and result.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"exchange", "key", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.serverRequests,
+		m.serverDuration,
+		m.clientRequests,
+		m.clientDuration,
+		m.rateLimit,
+		m.messages,
+		m.albumItems,
+		m.publishDuration,
+	)
+
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		Registry: m.registry,
+	})
+}
+
+func (m *Metrics) ObserveServerRequest(route, method string, statusCode int, elapsed time.Duration) {
+	m.serverRequests.WithLabelValues(route, method, strconv.Itoa(statusCode)).Inc()
+	m.serverDuration.WithLabelValues(route, method).Observe(elapsed.Seconds())
+}
+
+func (m *Metrics) ObserveClientRequest(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+	code := "error"
+	if err == nil && res != nil {
+		code = strconv.Itoa(res.StatusCode)
+	}
+
+	host, endpoint := Host(req.URL.Host), Endpoint(req.URL.Path)
+	m.clientRequests.WithLabelValues(host, endpoint, code).Inc()
+	m.clientDuration.WithLabelValues(host, endpoint).Observe(elapsed.Seconds())
+
+	if res == nil {
+		return
+	}
+
+	if limit, ok := imgur.ParseRateLimit(res.Header); ok {
+		m.rateLimit.WithLabelValues("client_limit").Set(float64(limit.ClientLimit))
+		m.rateLimit.WithLabelValues("client_remaining").Set(float64(limit.ClientRemaining))
+		m.rateLimit.WithLabelValues("user_limit").Set(float64(limit.UserLimit))
+		m.rateLimit.WithLabelValues("user_remaining").Set(float64(limit.UserRemaining))
+		if !limit.UserReset.IsZero() {
+			m.rateLimit.WithLabelValues("user_reset_timestamp").Set(float64(limit.UserReset.Unix()))
+		}
+	}
+}
+
+func (m *Metrics) ObserveMessage(queue, outcome string) {
+	m.messages.WithLabelValues(queue, outcome).Inc()
+}
+
+func (m *Metrics) ObserveAlbumItems(count int) {
+	m.albumItems.Observe(float64(count))
+}
+
+func (m *Metrics) ObservePublish(exchange, key string, err error, elapsed time.Duration) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+
+	m.publishDuration.WithLabelValues(exchange, key, result).Observe(elapsed.Seconds())
+}
+
+func Host(host string) string {
+	if _, ok := hosts[strings.ToLower(host)]; ok {
+		return strings.ToLower(host)
+	}
+
+	return otherLabel
+}
+
+func Endpoint(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case segments[0] == "":
+		return "/"
+	case segments[0] == "3" && len(segments) > 1 && slices.Contains(imgurEndpoints, segments[1]):
+		return "/3/" + segments[1]
+	case segments[0] == "3" || segments[0] == "comments":
+		return "/" + segments[0]
+	default:
+		return "/" + otherLabel
+	}
+}
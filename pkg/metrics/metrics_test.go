@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestEndpoint(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "Should drop identifiers from image endpoints",
+			path: "/3/image/some-image-id",
+			want: "/3/image",
+		},
+		{
+			name: "Should keep short paths as they are",
+			path: "/3",
+			want: "/3",
+		},
+		{
+			name: "Should handle the root path",
+			path: "/",
+			want: "/",
+		},
+		{
+			name: "Should drop identifiers from Reddit comments",
+			path: "/comments/abc123.json",
+			want: "/comments",
+		},
+		{
+			name: "Should collapse unknown paths",
+			path: "/some/user-supplied/path.jpg",
+			want: "/other",
+		},
+		{
+			name: "Should collapse unknown Imgur endpoints",
+			path: "/3/some-id/some-path",
+			want: "/3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Endpoint(tt.path); got != tt.want {
+				t.Errorf("Endpoint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHost(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		want string
+	}{
+		{
+			name: "Should keep API hosts",
+			host: "api.imgur.com",
+			want: "api.imgur.com",
+		},
+		{
+			name: "Should collapse any other host",
+			host: "some-host.example.com",
+			want: "other",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Host(tt.host); got != tt.want {
+				t.Errorf("Host() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetrics_ObserveClientRequest(t *testing.T) {
+	m := New()
+	req := &http.Request{
+		URL: &url.URL{Host: "api.imgur.com", Path: "/3/album/some-album-id"},
+	}
+	res := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header: http.Header{
+			"X-Ratelimit-Clientlimit":     []string{"12500"},
+			"X-Ratelimit-Clientremaining": []string{"42"},
+		},
+	}
+
+	m.ObserveClientRequest(req, res, nil, time.Millisecond)
+
+	if got := testutil.ToFloat64(m.clientRequests.WithLabelValues("api.imgur.com", "/3/album", "429")); got != 1 {
+		t.Errorf("requests_total = %v, want %v", got, 1)
+	}
+
+	if got := testutil.ToFloat64(m.rateLimit.WithLabelValues("client_remaining")); got != 42 {
+		t.Errorf("rate_limit{kind=client_remaining} = %v, want %v", got, 42)
+	}
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"context"
+	"github.com/alancesar/imgur-fetcher/pkg/media"
+	"time"
+)
+
+type (
+	Publisher interface {
+		Send(ctx context.Context, v any) error
+	}
+
+	InstrumentedPublisher struct {
+		metrics  *Metrics
+		exchange string
+		key      string
+		next     Publisher
+	}
+)
+
+func NewInstrumentedPublisher(m *Metrics, exchange, key string, next Publisher) *InstrumentedPublisher {
+	return &InstrumentedPublisher{
+		metrics:  m,
+		exchange: exchange,
+		key:      key,
+		next:     next,
+	}
+}
+
+func (p InstrumentedPublisher) Publish(ctx context.Context, m media.Media) error {
+	return p.Send(ctx, m)
+}
+
+func (p InstrumentedPublisher) Send(ctx context.Context, v any) error {
+	start := time.Now()
+	err := p.next.Send(ctx, v)
+	p.metrics.ObservePublish(p.exchange, p.key, err, time.Since(start))
+	return err
+}
@@ -0,0 +1,206 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/media"
+	"github.com/alancesar/imgur-fetcher/pkg/tracing"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/codes"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultQueueSize = 1024
+)
+
+var (
+	ErrBrokerClosed = errors.New("broker closed")
+)
+
+type (
+	Memory struct {
+		mu       sync.RWMutex
+		bindings map[string][]string
+		queues   map[string]chan amqp.Delivery
+		size     int
+		tag      atomic.Uint64
+		done     chan struct{}
+		once     sync.Once
+	}
+
+	MemoryPublisher struct {
+		broker   *Memory
+		exchange string
+		key      string
+	}
+
+	memoryAcknowledger struct {
+		broker *Memory
+		queue  string
+		id     string
+		body   []byte
+		header amqp.Table
+	}
+)
+
+func NewMemory(size int) *Memory {
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+
+	return &Memory{
+		bindings: map[string][]string{},
+		queues:   map[string]chan amqp.Delivery{},
+		size:     size,
+		done:     make(chan struct{}),
+	}
+}
+
+func (m *Memory) Bind(queue, exchange, key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.queue(queue)
+	route := exchange + "/" + key
+	m.bindings[route] = append(m.bindings[route], queue)
+}
+
+func (m *Memory) Consume(queue string) <-chan amqp.Delivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.queue(queue)
+}
+
+func (m *Memory) Publisher(exchange, key string) *MemoryPublisher {
+	return &MemoryPublisher{
+		broker:   m,
+		exchange: exchange,
+		key:      key,
+	}
+}
+
+func (m *Memory) IsClosed() bool {
+	select {
+	case <-m.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *Memory) Close() error {
+	m.once.Do(func() {
+		close(m.done)
+	})
+
+	return nil
+}
+
+func (m *Memory) queue(name string) chan amqp.Delivery {
+	q, exists := m.queues[name]
+	if !exists {
+		q = make(chan amqp.Delivery, m.size)
+		m.queues[name] = q
+	}
+
+	return q
+}
+
+func (m *Memory) publish(ctx context.Context, exchange, key, id string, body []byte, headers amqp.Table) error {
+	m.mu.RLock()
+	queues := m.bindings[exchange+"/"+key]
+	m.mu.RUnlock()
+
+	for _, queue := range queues {
+		if err := m.deliver(ctx, queue, exchange, key, id, body, headers, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) deliver(ctx context.Context, queue, exchange, key, id string, body []byte, headers amqp.Table, redelivered bool) error {
+	m.mu.RLock()
+	q := m.queues[queue]
+	m.mu.RUnlock()
+
+	delivery := amqp.Delivery{
+		Acknowledger: memoryAcknowledger{
+			broker: m,
+			queue:  queue,
+			id:     id,
+			body:   body,
+			header: headers,
+		},
+		Headers:     headers,
+		ContentType: "application/json",
+		MessageId:   id,
+		DeliveryTag: m.tag.Add(1),
+		Redelivered: redelivered,
+		Exchange:    exchange,
+		RoutingKey:  key,
+		Body:        body,
+	}
+
+	select {
+	case q <- delivery:
+		return nil
+	case <-m.done:
+		return ErrBrokerClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p MemoryPublisher) Publish(ctx context.Context, m media.Media) error {
+	return p.Send(ctx, m)
+}
+
+func (p MemoryPublisher) Send(ctx context.Context, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	if p.broker.IsClosed() {
+		return ErrBrokerClosed
+	}
+
+	ctx, span := tracing.StartPublish(ctx, p.exchange, p.key)
+	defer span.End()
+
+	if err := p.broker.publish(ctx, p.exchange, p.key, messageIDOf(v), body, tracing.Inject(ctx, nil)); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (p MemoryPublisher) IsClosed() bool {
+	return p.broker.IsClosed()
+}
+
+func (a memoryAcknowledger) Ack(_ uint64, _ bool) error {
+	return nil
+}
+
+func (a memoryAcknowledger) Nack(_ uint64, _ bool, requeue bool) error {
+	if requeue {
+		go func() {
+			_ = a.broker.deliver(context.Background(), a.queue, "", a.queue, a.id, a.body, a.header, true)
+		}()
+	}
+
+	return nil
+}
+
+func (a memoryAcknowledger) Reject(tag uint64, requeue bool) error {
+	return a.Nack(tag, false, requeue)
+}
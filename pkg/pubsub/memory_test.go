@@ -0,0 +1,67 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/pkg/media"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	broker := NewMemory(1)
+	broker.Bind("fetcher.imgur", "fetcher", "imgur")
+	deliveries := broker.Consume("fetcher.imgur")
+
+	want := media.Media{URL: "https://imgur.com/some-id", Parent: []string{"u", "someone"}}
+	if err := broker.Publisher("fetcher", "imgur").Publish(context.Background(), want); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	if err := broker.Publisher("fetcher", "unbound").Publish(context.Background(), want); err != nil {
+		t.Fatalf("Publish() to an unbound route error = %v", err)
+	}
+
+	first := receive(t, deliveries)
+	var got media.Media
+	if err := json.Unmarshal(first.Body, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Body = %v, want %v", got, want)
+	}
+
+	if first.MessageId != want.MessageID() {
+		t.Errorf("MessageId = %q, want %q", first.MessageId, want.MessageID())
+	}
+
+	if err := first.Nack(false, true); err != nil {
+		t.Fatalf("Nack() error = %v", err)
+	}
+
+	second := receive(t, deliveries)
+	if !second.Redelivered || string(second.Body) != string(first.Body) || second.MessageId != first.MessageId {
+		t.Errorf("Nack() should requeue the message, got %+v", second)
+	}
+
+	_ = broker.Close()
+	if err := broker.Publisher("fetcher", "imgur").Publish(context.Background(), want); !errors.Is(err, ErrBrokerClosed) {
+		t.Errorf("Publish() error = %v, wantErr %v", err, ErrBrokerClosed)
+	}
+}
+
+func receive(t *testing.T, deliveries <-chan amqp.Delivery) amqp.Delivery {
+	t.Helper()
+
+	select {
+	case delivery := <-deliveries:
+		return delivery
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a delivery")
+		return amqp.Delivery{}
+	}
+}
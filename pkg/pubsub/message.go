@@ -0,0 +1,30 @@
+package pubsub
+
+import (
+	"context"
+)
+
+type (
+	Identifiable interface {
+		MessageID() string
+	}
+
+	messageIDKey struct{}
+)
+
+func WithMessageID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, messageIDKey{}, id)
+}
+
+func MessageID(ctx context.Context) string {
+	id, _ := ctx.Value(messageIDKey{}).(string)
+	return id
+}
+
+func messageIDOf(v any) string {
+	if identifiable, ok := v.(Identifiable); ok {
+		return identifiable.MessageID()
+	}
+
+	return ""
+}
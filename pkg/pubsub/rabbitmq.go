@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/alancesar/imgur-fetcher/pkg/media"
+	"github.com/alancesar/imgur-fetcher/pkg/tracing"
 	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/codes"
 )
 
 type (
@@ -32,12 +34,19 @@ func NewRabbitMQPublisher(connection *amqp.Connection, exchange, key string) (*R
 }
 
 func (p RabbitMQPublisher) Publish(ctx context.Context, m media.Media) error {
-	body, err := json.Marshal(m)
+	return p.Send(ctx, m)
+}
+
+func (p RabbitMQPublisher) Send(ctx context.Context, v any) error {
+	body, err := json.Marshal(v)
 	if err != nil {
 		return fmt.Errorf("failed to unmarshal payload: %w", err)
 	}
 
-	return p.channel.PublishWithContext(
+	ctx, span := tracing.StartPublish(ctx, p.exchange, p.key)
+	defer span.End()
+
+	if err := p.channel.PublishWithContext(
 		ctx,
 		p.exchange,
 		p.key,
@@ -45,9 +54,20 @@ func (p RabbitMQPublisher) Publish(ctx context.Context, m media.Media) error {
 		false,
 		amqp.Publishing{
 			ContentType: "application/json",
+			MessageId:   messageIDOf(v),
+			Headers:     tracing.Inject(ctx, nil),
 			Body:        body,
 		},
-	)
+	); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (p RabbitMQPublisher) IsClosed() bool {
+	return p.channel.IsClosed()
 }
 
 func (p RabbitMQPublisher) Close() error {
@@ -0,0 +1,404 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"github.com/alancesar/imgur-fetcher/pkg/resolver"
+	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	ProviderName = "reddit"
+
+	apiPath = "https://www.reddit.com"
+	mp4Type = "video/mp4"
+)
+
+var (
+	Hosts = []string{"reddit.com", "redd.it"}
+)
+
+type (
+	Client struct {
+		httpClient *http.Client
+		links      resolver.Resolver
+		logger     *slog.Logger
+	}
+
+	Listing struct {
+		Data struct {
+			Children []struct {
+				Kind string `json:"kind"`
+				Data Post   `json:"data"`
+			} `json:"children"`
+		} `json:"data"`
+	}
+
+	Post struct {
+		ID            string                   `json:"id"`
+		Title         string                   `json:"title"`
+		URL           string                   `json:"url"`
+		Domain        string                   `json:"domain"`
+		PostHint      string                   `json:"post_hint"`
+		IsGallery     bool                     `json:"is_gallery"`
+		IsVideo       bool                     `json:"is_video"`
+		GalleryData   *GalleryData             `json:"gallery_data"`
+		MediaMetadata map[string]MediaMetadata `json:"media_metadata"`
+		SecureMedia   *SecureMedia             `json:"secure_media"`
+		Media         *SecureMedia             `json:"media"`
+		Crossposts    []Post                   `json:"crosspost_parent_list"`
+	}
+
+	GalleryData struct {
+		Items []struct {
+			MediaID string `json:"media_id"`
+			Caption string `json:"caption"`
+		} `json:"items"`
+	}
+
+	MediaMetadata struct {
+		Status string `json:"status"`
+		Kind   string `json:"e"`
+		Mime   string `json:"m"`
+		Source struct {
+			Width  int    `json:"x"`
+			Height int    `json:"y"`
+			URL    string `json:"u"`
+			GIF    string `json:"gif"`
+			MP4    string `json:"mp4"`
+		} `json:"s"`
+	}
+
+	SecureMedia struct {
+		RedditVideo *Video `json:"reddit_video"`
+	}
+
+	Video struct {
+		FallbackURL string `json:"fallback_url"`
+		HLSURL      string `json:"hls_url"`
+		DASHURL     string `json:"dash_url"`
+		Width       int    `json:"width"`
+		Height      int    `json:"height"`
+		BitrateKbps int    `json:"bitrate_kbps"`
+	}
+)
+
+func NewClient(httpClient *http.Client, links resolver.Resolver, logger *slog.Logger) *Client {
+	return &Client{
+		httpClient: httpClient,
+		links:      links,
+		logger:     logger,
+	}
+}
+
+func (c Client) Resolve(ctx context.Context, rawURL string, options resolver.Options) ([]resolver.Media, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	switch {
+	case resolver.MatchHost(host, "i.redd.it"):
+		return []resolver.Media{image(parsed)}, nil
+	case resolver.MatchHost(host, "v.redd.it"):
+		permalink, ok, err := c.permalink(ctx, parsed)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return []resolver.Media{video(strings.Trim(parsed.Path, "/"))}, nil
+		}
+
+		parsed = permalink
+	}
+
+	if isShareLink(parsed) {
+		if parsed, err = c.expand(ctx, rawURL); err != nil {
+			return nil, err
+		}
+	}
+
+	id, ok := PostID(parsed)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a reddit post", resolver.ErrUnsupported, rawURL)
+	}
+
+	ctx = logging.With(ctx, "reddit_id", id)
+	c.logger.DebugContext(ctx, "resolving reddit post")
+	post, err := c.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaList, err := post.Resolved()
+	if errors.Is(err, resolver.ErrUnsupported) && c.links != nil {
+		link := post.Link()
+		c.logger.DebugContext(ctx, "reddit post links elsewhere, delegating", logging.KeyURL, link)
+		return c.links.Resolve(ctx, link, options)
+	}
+
+	return mediaList, err
+}
+
+func (c Client) GetPost(ctx context.Context, id string) (Post, error) {
+	var listings []Listing
+	formattedURL := fmt.Sprintf("%s/comments/%s.json?raw_json=1", apiPath, url.PathEscape(id))
+	if err := c.doGet(ctx, formattedURL, &listings); err != nil {
+		return Post{}, err
+	}
+
+	if len(listings) == 0 || len(listings[0].Data.Children) == 0 {
+		return Post{}, fmt.Errorf("%w: %s", status.ErrNotFound, formattedURL)
+	}
+
+	return listings[0].Data.Children[0].Data, nil
+}
+
+func (c Client) expand(ctx context.Context, rawURL string) (*url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", status.ErrNotFound, rawURL)
+	}
+
+	return res.Request.URL, nil
+}
+
+func (c Client) permalink(ctx context.Context, u *url.URL) (*url.URL, bool, error) {
+	id, _, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	permalink, err := c.expand(ctx, "https://v.redd.it/"+url.PathEscape(id))
+	if errors.Is(err, status.ErrNotFound) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	_, ok := PostID(permalink)
+	return permalink, ok, nil
+}
+
+func (c Client) doGet(ctx context.Context, url string, output any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	c.logger.DebugContext(ctx, "reddit api call", "endpoint", url, "status", res.StatusCode)
+
+	if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%w: %s", status.ErrNotFound, url)
+	} else if res.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%w: %d (%s): %s", status.ErrBadStatus, res.StatusCode, res.Status, url)
+	}
+
+	return json.NewDecoder(res.Body).Decode(output)
+}
+
+func (p Post) Resolved() ([]resolver.Media, error) {
+	if len(p.Crossposts) > 0 {
+		return p.Crossposts[0].Resolved()
+	}
+
+	if p.IsGallery && p.GalleryData != nil {
+		return p.gallery(), nil
+	}
+
+	if v := p.video(); v != nil {
+		m := v.Resolved(strings.TrimPrefix(p.URL, "https://v.redd.it/"))
+		m.ID, m.Title = mediaID(p.ID), p.Title
+		return []resolver.Media{m}, nil
+	}
+
+	parsed, err := url.Parse(p.URL)
+	if err == nil && resolver.MatchHost(parsed.Hostname(), "i.redd.it") {
+		m := image(parsed)
+		m.ID, m.Title = mediaID(p.ID), p.Title
+		return []resolver.Media{m}, nil
+	}
+
+	return nil, fmt.Errorf("%w: reddit post %s links to %s", resolver.ErrUnsupported, p.ID, p.URL)
+}
+
+func (p Post) Link() string {
+	if len(p.Crossposts) > 0 {
+		return p.Crossposts[0].Link()
+	}
+
+	return p.URL
+}
+
+func (p Post) gallery() []resolver.Media {
+	mediaList := make([]resolver.Media, 0, len(p.GalleryData.Items))
+	for _, item := range p.GalleryData.Items {
+		metadata, ok := p.MediaMetadata[item.MediaID]
+		if !ok || metadata.Status != "valid" {
+			continue
+		}
+
+		title := item.Caption
+		if title == "" {
+			title = p.Title
+		}
+
+		m := resolver.Media{
+			ID:       mediaID(item.MediaID),
+			Provider: ProviderName,
+			Title:    title,
+		}
+
+		if metadata.Kind == "AnimatedImage" && metadata.Source.MP4 != "" {
+			mp4 := resolver.Variant{URL: metadata.Source.MP4, Type: "video/mp4", Width: metadata.Source.Width, Height: metadata.Source.Height}
+			m.URL, m.Type = mp4.URL, mp4.Type
+			m.Variants = []resolver.Variant{mp4}
+
+			if metadata.Source.GIF != "" {
+				m.URL, m.Type = metadata.Source.GIF, "image/gif"
+				m.Variants = []resolver.Variant{
+					{URL: metadata.Source.GIF, Type: "image/gif", Width: metadata.Source.Width, Height: metadata.Source.Height},
+					mp4,
+				}
+			}
+		} else {
+			mediaType := strings.Replace(metadata.Mime, "image/jpg", "image/jpeg", 1)
+			m.URL, m.Type = "https://i.redd.it/"+item.MediaID+extension(mediaType), mediaType
+			m.Variants = []resolver.Variant{
+				{URL: m.URL, Type: mediaType, Width: metadata.Source.Width, Height: metadata.Source.Height},
+			}
+		}
+
+		mediaList = append(mediaList, m)
+	}
+
+	return mediaList
+}
+
+func (p Post) video() *Video {
+	if p.SecureMedia != nil && p.SecureMedia.RedditVideo != nil {
+		return p.SecureMedia.RedditVideo
+	}
+
+	if p.Media != nil && p.Media.RedditVideo != nil {
+		return p.Media.RedditVideo
+	}
+
+	return nil
+}
+
+func (v Video) Resolved(id string) resolver.Media {
+	m := video(id)
+	if v.HLSURL != "" {
+		m.URL = v.HLSURL
+		m.Variants = []resolver.Variant{
+			{URL: v.HLSURL, Type: resolver.TypeHLS, Width: v.Width, Height: v.Height},
+		}
+
+		if v.DASHURL != "" {
+			m.Variants = append(m.Variants, resolver.Variant{URL: v.DASHURL, Type: resolver.TypeDASH, Width: v.Width, Height: v.Height})
+		}
+	}
+
+	if v.FallbackURL != "" {
+		m.URL, m.Type = v.FallbackURL, mp4Type
+		m.Variants = append(m.Variants, resolver.Variant{
+			URL:       v.FallbackURL,
+			Type:      mp4Type,
+			Width:     v.Width,
+			Height:    v.Height,
+			Bandwidth: v.BitrateKbps * 1000,
+		})
+	}
+
+	return m
+}
+
+func PostID(u *url.URL) (string, bool) {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if resolver.MatchHost(u.Hostname(), "redd.it") && !resolver.MatchHost(u.Hostname(), "i.redd.it", "v.redd.it") {
+		return segments[0], len(segments) == 1 && segments[0] != ""
+	}
+
+	for i, segment := range segments {
+		if (segment == "comments" || segment == "gallery") && i+1 < len(segments) && segments[i+1] != "" {
+			return segments[i+1], true
+		}
+	}
+
+	return "", false
+}
+
+func isShareLink(u *url.URL) bool {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	return len(segments) == 4 && segments[0] == "r" && segments[2] == "s"
+}
+
+func image(u *url.URL) resolver.Media {
+	name := path.Base(u.Path)
+	return resolver.Media{
+		ID:       mediaID(strings.TrimSuffix(name, path.Ext(name))),
+		Provider: ProviderName,
+		URL:      "https://i.redd.it/" + name,
+		Type:     mime.TypeByExtension(path.Ext(name)),
+	}
+}
+
+func video(id string) resolver.Media {
+	id, _, _ = strings.Cut(id, "/")
+	hls := "https://v.redd.it/" + id + "/HLSPlaylist.m3u8"
+	return resolver.Media{
+		ID:       mediaID(id),
+		Provider: ProviderName,
+		URL:      hls,
+		Type:     resolver.TypeHLS,
+		Variants: []resolver.Variant{
+			{URL: hls, Type: resolver.TypeHLS},
+			{URL: "https://v.redd.it/" + id + "/DASHPlaylist.mpd", Type: resolver.TypeDASH},
+		},
+	}
+}
+
+func mediaID(id string) string {
+	return ProviderName + "-" + id
+}
+
+func extension(mediaType string) string {
+	switch mediaType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ""
+	}
+}
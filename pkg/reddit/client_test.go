@@ -0,0 +1,196 @@
+package reddit
+
+import (
+	"context"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/pkg/logging"
+	"github.com/alancesar/imgur-fetcher/pkg/reddit/testdata"
+	"github.com/alancesar/imgur-fetcher/pkg/resolver"
+	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"github.com/alancesar/imgur-fetcher/pkg/urlpolicy"
+	"reflect"
+	"testing"
+)
+
+type (
+	fakeResolver struct{}
+)
+
+func (fakeResolver) Resolve(_ context.Context, rawURL string, _ resolver.Options) ([]resolver.Media, error) {
+	return []resolver.Media{{ID: "delegated", Provider: "fake", URL: rawURL}}, nil
+}
+
+func TestClient_Resolve(t *testing.T) {
+	httpClient := testdata.NewHTTPClient(map[string]string{
+		"/comments/img001.json":                      testdata.RedditImagePost,
+		"/comments/gal001.json":                      testdata.RedditGalleryPost,
+		"/comments/vid001.json":                      testdata.RedditVideoPost,
+		"/comments/lnk001.json":                      testdata.RedditLinkPost,
+		"/comments/img002.json":                      testdata.RedditImgurPost,
+		"/comments/xps001.json":                      testdata.RedditCrosspost,
+		"/r/pics/s/AbCdEf":                           testdata.Redirect("https://www.reddit.com/r/pics/comments/img001/some_image_post/"),
+		"/r/pics/s/GhIjKl":                           testdata.Redirect("http://169.254.169.254/latest/meta-data/"),
+		"/r/pics/comments/img001/some_image_post/":   "",
+		"/postvideo":                                 testdata.Redirect("https://www.reddit.com/r/videos/comments/vid001/some_video_post/"),
+		"/r/videos/comments/vid001/some_video_post/": "",
+	})
+
+	httpClient.CheckRedirect = urlpolicy.Policy{AllowedHosts: Hosts}.CheckRedirect
+
+	links := resolver.NewRegistry()
+	links.Register(fakeResolver{}, "imgur.com")
+
+	tests := []struct {
+		name    string
+		url     string
+		want    []resolver.Media
+		wantErr error
+	}{
+		{
+			name: "Should resolve i.redd.it links without calling the API",
+			url:  "https://i.redd.it/direct-image.png",
+			want: []resolver.Media{
+				{ID: "reddit-direct-image", Provider: ProviderName, URL: "https://i.redd.it/direct-image.png", Type: "image/png"},
+			},
+		},
+		{
+			name: "Should resolve v.redd.it links into their playlists",
+			url:  "https://v.redd.it/somevideo",
+			want: []resolver.Media{
+				{
+					ID:       "reddit-somevideo",
+					Provider: ProviderName,
+					URL:      "https://v.redd.it/somevideo/HLSPlaylist.m3u8",
+					Type:     resolver.TypeHLS,
+					Variants: []resolver.Variant{
+						{URL: "https://v.redd.it/somevideo/HLSPlaylist.m3u8", Type: resolver.TypeHLS},
+						{URL: "https://v.redd.it/somevideo/DASHPlaylist.mpd", Type: resolver.TypeDASH},
+					},
+				},
+			},
+		},
+		{
+			name: "Should resolve image posts",
+			url:  "https://www.reddit.com/r/pics/comments/img001/some_image_post/",
+			want: []resolver.Media{
+				{ID: "reddit-img001", Provider: ProviderName, Title: "Some image post", URL: "https://i.redd.it/some-image.jpg", Type: "image/jpeg"},
+			},
+		},
+		{
+			name: "Should resolve gallery items and skip failed ones",
+			url:  "https://www.reddit.com/gallery/gal001",
+			want: []resolver.Media{
+				{
+					ID:       "reddit-firstmedia",
+					Provider: ProviderName,
+					Title:    "First caption",
+					URL:      "https://i.redd.it/firstmedia.jpg",
+					Type:     "image/jpeg",
+					Variants: []resolver.Variant{
+						{URL: "https://i.redd.it/firstmedia.jpg", Type: "image/jpeg", Width: 1920, Height: 1080},
+					},
+				},
+				{
+					ID:       "reddit-secondmedia",
+					Provider: ProviderName,
+					Title:    "Some gallery post",
+					URL:      "https://i.redd.it/secondmedia.gif",
+					Type:     "image/gif",
+					Variants: []resolver.Variant{
+						{URL: "https://i.redd.it/secondmedia.gif", Type: "image/gif", Width: 480, Height: 270},
+						{URL: "https://preview.redd.it/secondmedia.gif?format=mp4&s=def", Type: "video/mp4", Width: 480, Height: 270},
+					},
+				},
+			},
+		},
+		{
+			name: "Should resolve hosted videos from short links",
+			url:  "https://redd.it/vid001",
+			want: []resolver.Media{
+				{
+					ID:       "reddit-vid001",
+					Provider: ProviderName,
+					Title:    "Some video post",
+					URL:      "https://v.redd.it/somevideo/DASH_720.mp4?source=fallback",
+					Type:     "video/mp4",
+					Variants: []resolver.Variant{
+						{URL: "https://v.redd.it/somevideo/HLSPlaylist.m3u8?a=1", Type: resolver.TypeHLS, Width: 1280, Height: 720},
+						{URL: "https://v.redd.it/somevideo/DASHPlaylist.mpd?a=1", Type: resolver.TypeDASH, Width: 1280, Height: 720},
+						{URL: "https://v.redd.it/somevideo/DASH_720.mp4?source=fallback", Type: "video/mp4", Width: 1280, Height: 720, Bandwidth: 2400000},
+					},
+				},
+			},
+		},
+		{
+			name: "Should resolve v.redd.it links of posts into their muxed mp4",
+			url:  "https://v.redd.it/postvideo/HLSPlaylist.m3u8",
+			want: []resolver.Media{
+				{
+					ID:       "reddit-vid001",
+					Provider: ProviderName,
+					Title:    "Some video post",
+					URL:      "https://v.redd.it/somevideo/DASH_720.mp4?source=fallback",
+					Type:     "video/mp4",
+					Variants: []resolver.Variant{
+						{URL: "https://v.redd.it/somevideo/HLSPlaylist.m3u8?a=1", Type: resolver.TypeHLS, Width: 1280, Height: 720},
+						{URL: "https://v.redd.it/somevideo/DASHPlaylist.mpd?a=1", Type: resolver.TypeDASH, Width: 1280, Height: 720},
+						{URL: "https://v.redd.it/somevideo/DASH_720.mp4?source=fallback", Type: "video/mp4", Width: 1280, Height: 720, Bandwidth: 2400000},
+					},
+				},
+			},
+		},
+		{
+			name: "Should delegate posts linking to imgur",
+			url:  "https://www.reddit.com/user/someone/comments/img002/some_imgur_post/",
+			want: []resolver.Media{
+				{ID: "delegated", Provider: "fake", URL: "https://i.imgur.com/abc123.gifv"},
+			},
+		},
+		{
+			name: "Should follow crossposts to their parent post",
+			url:  "https://m.reddit.com/r/videos/comments/xps001/some_crosspost/",
+			want: []resolver.Media{
+				{ID: "delegated", Provider: "fake", URL: "https://i.imgur.com/abc123.gifv"},
+			},
+		},
+		{
+			name: "Should expand share links into permalinks",
+			url:  "https://www.reddit.com/r/pics/s/AbCdEf",
+			want: []resolver.Media{
+				{ID: "reddit-img001", Provider: ProviderName, Title: "Some image post", URL: "https://i.redd.it/some-image.jpg", Type: "image/jpeg"},
+			},
+		},
+		{
+			name:    "Should not follow share links outside reddit",
+			url:     "https://www.reddit.com/r/pics/s/GhIjKl",
+			wantErr: urlpolicy.ErrRejected,
+		},
+		{
+			name:    "Should not resolve posts linking elsewhere",
+			url:     "https://old.reddit.com/r/news/comments/lnk001/",
+			wantErr: resolver.ErrUnsupported,
+		},
+		{
+			name:    "Should return not found for missing posts",
+			url:     "https://www.reddit.com/comments/missing",
+			wantErr: status.ErrNotFound,
+		},
+		{
+			name:    "Should not resolve subreddit pages",
+			url:     "https://www.reddit.com/r/pics",
+			wantErr: resolver.ErrUnsupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewClient(httpClient, links, logging.Discard()).Resolve(context.Background(), tt.url, resolver.Options{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
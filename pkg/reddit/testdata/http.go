@@ -0,0 +1,56 @@
+package testdata
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	redirectPrefix = "redirect:"
+)
+
+type (
+	Transport struct {
+		responses map[string]string
+	}
+)
+
+func (m Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, ok := m.responses[request.URL.Path]
+	statusCode := http.StatusOK
+	if !ok {
+		statusCode = http.StatusNotFound
+	}
+
+	if location, ok := strings.CutPrefix(response, redirectPrefix); ok {
+		return &http.Response{
+			Status:     http.StatusText(http.StatusMovedPermanently),
+			StatusCode: http.StatusMovedPermanently,
+			Header:     http.Header{"Location": []string{location}},
+			Body:       io.NopCloser(strings.NewReader("")),
+			Request:    request,
+		}, nil
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(statusCode),
+		StatusCode:    statusCode,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(response)),
+		ContentLength: int64(len(response)),
+		Request:       request,
+	}, nil
+}
+
+func NewHTTPClient(responses map[string]string) *http.Client {
+	return &http.Client{
+		Transport: Transport{
+			responses: responses,
+		},
+	}
+}
+
+func Redirect(location string) string {
+	return redirectPrefix + location
+}
@@ -0,0 +1,215 @@
+package testdata
+
+const RedditImagePost = `
+[
+  {
+    "kind": "Listing",
+    "data": {
+      "children": [
+        {
+          "kind": "t3",
+          "data": {
+            "id": "img001",
+            "title": "Some image post",
+            "domain": "i.redd.it",
+            "post_hint": "image",
+            "url": "https:\/\/i.redd.it\/some-image.jpg",
+            "is_gallery": false,
+            "is_video": false,
+            "secure_media": null,
+            "media": null
+          }
+        }
+      ]
+    }
+  },
+  {
+    "kind": "Listing",
+    "data": {
+      "children": []
+    }
+  }
+]
+`
+
+const RedditGalleryPost = `
+[
+  {
+    "kind": "Listing",
+    "data": {
+      "children": [
+        {
+          "kind": "t3",
+          "data": {
+            "id": "gal001",
+            "title": "Some gallery post",
+            "domain": "reddit.com",
+            "url": "https:\/\/www.reddit.com\/gallery\/gal001",
+            "is_gallery": true,
+            "is_video": false,
+            "gallery_data": {
+              "items": [
+                {"media_id": "firstmedia", "id": 1, "caption": "First caption"},
+                {"media_id": "secondmedia", "id": 2},
+                {"media_id": "failedmedia", "id": 3}
+              ]
+            },
+            "media_metadata": {
+              "firstmedia": {
+                "status": "valid",
+                "e": "Image",
+                "m": "image\/jpg",
+                "s": {"x": 1920, "y": 1080, "u": "https:\/\/preview.redd.it\/firstmedia.jpg?width=1920&format=pjpg&auto=webp&s=abc"}
+              },
+              "secondmedia": {
+                "status": "valid",
+                "e": "AnimatedImage",
+                "m": "image\/gif",
+                "s": {
+                  "x": 480,
+                  "y": 270,
+                  "gif": "https:\/\/i.redd.it\/secondmedia.gif",
+                  "mp4": "https:\/\/preview.redd.it\/secondmedia.gif?format=mp4&s=def"
+                }
+              },
+              "failedmedia": {
+                "status": "failed"
+              }
+            }
+          }
+        }
+      ]
+    }
+  },
+  {
+    "kind": "Listing",
+    "data": {
+      "children": []
+    }
+  }
+]
+`
+
+const RedditVideoPost = `
+[
+  {
+    "kind": "Listing",
+    "data": {
+      "children": [
+        {
+          "kind": "t3",
+          "data": {
+            "id": "vid001",
+            "title": "Some video post",
+            "domain": "v.redd.it",
+            "post_hint": "hosted:video",
+            "url": "https:\/\/v.redd.it\/somevideo",
+            "is_gallery": false,
+            "is_video": true,
+            "secure_media": {
+              "reddit_video": {
+                "bitrate_kbps": 2400,
+                "fallback_url": "https:\/\/v.redd.it\/somevideo\/DASH_720.mp4?source=fallback",
+                "height": 720,
+                "width": 1280,
+                "dash_url": "https:\/\/v.redd.it\/somevideo\/DASHPlaylist.mpd?a=1",
+                "duration": 12,
+                "hls_url": "https:\/\/v.redd.it\/somevideo\/HLSPlaylist.m3u8?a=1",
+                "is_gif": false
+              }
+            }
+          }
+        }
+      ]
+    }
+  },
+  {
+    "kind": "Listing",
+    "data": {
+      "children": []
+    }
+  }
+]
+`
+
+const RedditLinkPost = `
+[
+  {
+    "kind": "Listing",
+    "data": {
+      "children": [
+        {
+          "kind": "t3",
+          "data": {
+            "id": "lnk001",
+            "title": "Some link post",
+            "domain": "example.com",
+            "post_hint": "link",
+            "url": "https:\/\/example.com\/some-article",
+            "is_gallery": false,
+            "is_video": false
+          }
+        }
+      ]
+    }
+  }
+]
+`
+
+const RedditImgurPost = `
+[
+  {
+    "kind": "Listing",
+    "data": {
+      "children": [
+        {
+          "kind": "t3",
+          "data": {
+            "id": "img002",
+            "title": "Some imgur post",
+            "domain": "i.imgur.com",
+            "post_hint": "link",
+            "url": "https:\/\/i.imgur.com\/abc123.gifv",
+            "is_gallery": false,
+            "is_video": false
+          }
+        }
+      ]
+    }
+  }
+]
+`
+
+const RedditCrosspost = `
+[
+  {
+    "kind": "Listing",
+    "data": {
+      "children": [
+        {
+          "kind": "t3",
+          "data": {
+            "id": "xps001",
+            "title": "Some crosspost",
+            "domain": "self.videos",
+            "url": "\/r\/videos\/comments\/vid001\/some_video_post\/",
+            "is_gallery": false,
+            "is_video": false,
+            "crosspost_parent_list": [
+              {
+                "id": "img002",
+                "title": "Some imgur post",
+                "domain": "i.imgur.com",
+                "post_hint": "link",
+                "url": "https:\/\/i.imgur.com\/abc123.gifv",
+                "is_gallery": false,
+                "is_video": false
+              }
+            ]
+          }
+        }
+      ]
+    }
+  }
+]
+`
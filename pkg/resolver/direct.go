@@ -0,0 +1,84 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	ProviderDirect = "direct"
+)
+
+type (
+	Direct struct {
+		httpClient *http.Client
+	}
+)
+
+func NewDirect(httpClient *http.Client) *Direct {
+	return &Direct{
+		httpClient: httpClient,
+	}
+}
+
+func (d Direct) Resolve(ctx context.Context, rawURL string, _ Options) ([]Media, error) {
+	res, err := d.probe(ctx, http.MethodHead, rawURL)
+	if err == nil && (res.StatusCode == http.StatusMethodNotAllowed || res.StatusCode == http.StatusNotImplemented) {
+		res, err = d.probe(ctx, http.MethodGet, rawURL)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone {
+		return nil, fmt.Errorf("%w: %s", status.ErrNotFound, rawURL)
+	} else if res.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("%w: %d (%s): %s", status.ErrBadStatus, res.StatusCode, res.Status, rawURL)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil || !IsMediaType(mediaType) {
+		return nil, fmt.Errorf("%w: %s is not a media file", ErrUnsupported, rawURL)
+	}
+
+	size := res.ContentLength
+	if size < 0 || res.Request.Method != http.MethodHead {
+		size = 0
+	}
+
+	return []Media{{
+		Provider: ProviderDirect,
+		URL:      res.Request.URL.String(),
+		Type:     mediaType,
+		Size:     size,
+	}}, nil
+}
+
+func (d Direct) probe(ctx context.Context, method, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+
+	res, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = res.Body.Close()
+	return res, nil
+}
+
+func IsMediaType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "image/") || strings.HasPrefix(mediaType, "video/") ||
+		mediaType == TypeHLS || mediaType == TypeDASH
+}
@@ -0,0 +1,171 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	ProviderOpenGraph = "opengraph"
+
+	maxPageSize = 1 << 20
+)
+
+type (
+	OpenGraph struct {
+		httpClient *http.Client
+	}
+
+	openGraphTags struct {
+		title  string
+		images []Variant
+		videos []Variant
+	}
+)
+
+func NewOpenGraph(httpClient *http.Client) *OpenGraph {
+	return &OpenGraph{
+		httpClient: httpClient,
+	}
+}
+
+func (o OpenGraph) Resolve(ctx context.Context, rawURL string, _ Options) ([]Media, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "text/html")
+	res, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone {
+		return nil, fmt.Errorf("%w: %s", status.ErrNotFound, rawURL)
+	} else if res.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("%w: %d (%s): %s", status.ErrBadStatus, res.StatusCode, res.Status, rawURL)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("%w: %s is not an html page", ErrUnsupported, rawURL)
+	}
+
+	tags := parseOpenGraph(io.LimitReader(res.Body, maxPageSize), res.Request.URL)
+	variants := tags.videos
+	if len(variants) == 0 {
+		variants = tags.images
+	}
+
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("%w: %s has no og:image or og:video", ErrUnsupported, rawURL)
+	}
+
+	mediaList := make([]Media, 0, len(variants))
+	for _, v := range variants {
+		mediaList = append(mediaList, Media{
+			Provider: ProviderOpenGraph,
+			Title:    tags.title,
+			URL:      v.URL,
+			Type:     v.Type,
+		})
+	}
+
+	return mediaList, nil
+}
+
+func parseOpenGraph(r io.Reader, base *url.URL) openGraphTags {
+	var tags openGraphTags
+	seen := map[string]bool{}
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return tags
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); atom.Lookup(name) == atom.Head {
+				return tags
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			if atom.Lookup(name) != atom.Meta || !hasAttr {
+				continue
+			}
+
+			property, content := metaAttributes(tokenizer)
+			switch property {
+			case "og:title":
+				tags.title = content
+			case "og:image", "og:image:url", "og:image:secure_url":
+				tags.images = addVariant(tags.images, seen, base, property, content)
+			case "og:video", "og:video:url", "og:video:secure_url":
+				tags.videos = addVariant(tags.videos, seen, base, property, content)
+			case "og:image:type":
+				setType(tags.images, content)
+			case "og:video:type":
+				setType(tags.videos, content)
+			}
+		}
+	}
+}
+
+func metaAttributes(tokenizer *html.Tokenizer) (string, string) {
+	var property, content string
+	for {
+		key, value, more := tokenizer.TagAttr()
+		switch string(key) {
+		case "property", "name":
+			property = strings.ToLower(strings.TrimSpace(string(value)))
+		case "content":
+			content = strings.TrimSpace(string(value))
+		}
+
+		if !more {
+			return property, content
+		}
+	}
+}
+
+func addVariant(variants []Variant, seen map[string]bool, base *url.URL, property, content string) []Variant {
+	ref, err := url.Parse(content)
+	if err != nil || content == "" {
+		return variants
+	}
+
+	resolved := base.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return variants
+	}
+
+	if strings.HasSuffix(property, ":secure_url") && len(variants) > 0 {
+		seen[resolved.String()] = true
+		variants[len(variants)-1].URL = resolved.String()
+		return variants
+	}
+
+	if seen[resolved.String()] {
+		return variants
+	}
+
+	seen[resolved.String()] = true
+	return append(variants, Variant{URL: resolved.String()})
+}
+
+func setType(variants []Variant, mediaType string) {
+	if len(variants) > 0 {
+		variants[len(variants)-1].Type = mediaType
+	}
+}
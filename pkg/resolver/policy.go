@@ -0,0 +1,173 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	PolicyMP4      = "mp4"
+	PolicySmallest = "smallest"
+	PolicyOriginal = "original"
+	PolicyAll      = "all"
+
+	mp4Type = "video/mp4"
+	gifType = "image/gif"
+)
+
+var (
+	ErrInvalidPolicy = errors.New("invalid variant policy")
+
+	Policies = []string{PolicyMP4, PolicySmallest, PolicyOriginal, PolicyAll}
+)
+
+type (
+	Policy struct {
+		Name    string
+		MaxSize int64
+	}
+)
+
+func ParsePolicy(name string, maxSize int64) (Policy, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = PolicyMP4
+	}
+
+	switch name {
+	case PolicyMP4, PolicySmallest, PolicyOriginal, PolicyAll:
+	default:
+		return Policy{}, fmt.Errorf("%w: %q must be one of %s", ErrInvalidPolicy, name, strings.Join(Policies, ", "))
+	}
+
+	if maxSize < 0 {
+		return Policy{}, fmt.Errorf("%w: max size must not be negative", ErrInvalidPolicy)
+	}
+
+	return Policy{Name: name, MaxSize: maxSize}, nil
+}
+
+func (p Policy) Apply(mediaList []Media) []Media {
+	selected := make([]Media, 0, len(mediaList))
+	for _, m := range mediaList {
+		if p.Name == PolicyAll {
+			selected = append(selected, p.expand(m)...)
+			continue
+		}
+
+		if v, ok := p.choose(m); ok {
+			selected = append(selected, m.with(v))
+		}
+	}
+
+	return selected
+}
+
+func (p Policy) choose(m Media) (Variant, bool) {
+	for _, v := range p.candidates(m) {
+		if p.fits(v) {
+			return v, true
+		}
+	}
+
+	return Variant{}, false
+}
+
+func (p Policy) candidates(m Media) []Variant {
+	original := m.original()
+	switch p.Name {
+	case PolicySmallest:
+		return []Variant{smallest(original, m.Variants)}
+	case PolicyOriginal:
+		return []Variant{original}
+	}
+
+	if !isGIF(original.Type) {
+		return []Variant{original}
+	}
+
+	return append(mp4s(m.Variants), original)
+}
+
+func (p Policy) expand(m Media) []Media {
+	original := m.original()
+	var expanded []Media
+	if p.fits(original) {
+		expanded = append(expanded, m.with(original))
+	}
+
+	seen := map[string]struct{}{original.URL: {}}
+	for i, v := range m.Variants {
+		if _, ok := seen[v.URL]; ok || !p.fits(v) {
+			continue
+		}
+
+		seen[v.URL] = struct{}{}
+		variant := m.with(v)
+		variant.ID = m.ID + "-" + strconv.Itoa(i+1)
+		expanded = append(expanded, variant)
+	}
+
+	return expanded
+}
+
+func (p Policy) fits(v Variant) bool {
+	return p.MaxSize <= 0 || v.Size <= p.MaxSize
+}
+
+func (m Media) original() Variant {
+	return Variant{URL: m.URL, Type: m.Type, Size: m.Size}
+}
+
+func (m Media) with(v Variant) Media {
+	m.URL = v.URL
+	m.Type = v.Type
+	m.Size = v.Size
+	return m
+}
+
+func smallest(original Variant, variants []Variant) Variant {
+	chosen := original
+	for _, v := range variants {
+		if v.Size <= 0 || isStream(v.Type) {
+			continue
+		}
+
+		if chosen.Size <= 0 || v.Size < chosen.Size {
+			chosen = v
+		}
+	}
+
+	return chosen
+}
+
+func mp4s(variants []Variant) []Variant {
+	var found []Variant
+	for _, v := range variants {
+		if v.Type == mp4Type {
+			found = append(found, v)
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.Width*a.Height != b.Width*b.Height {
+			return a.Width*a.Height > b.Width*b.Height
+		}
+
+		return a.Size > b.Size
+	})
+
+	return found
+}
+
+func isGIF(contentType string) bool {
+	return contentType == gifType
+}
+
+func isStream(contentType string) bool {
+	return contentType == TypeHLS || contentType == TypeDASH
+}
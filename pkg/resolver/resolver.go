@@ -0,0 +1,142 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/urlpolicy"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const (
+	TypeHLS  = "application/vnd.apple.mpegurl"
+	TypeDASH = "application/dash+xml"
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported url")
+)
+
+type (
+	Resolver interface {
+		Resolve(ctx context.Context, rawURL string, options Options) ([]Media, error)
+	}
+
+	Options struct {
+		Sort     string
+		Window   string
+		MaxPages int
+	}
+
+	Media struct {
+		ID          string    `json:"id,omitempty"`
+		Provider    string    `json:"provider"`
+		Title       string    `json:"title,omitempty"`
+		Description string    `json:"description,omitempty"`
+		URL         string    `json:"url"`
+		Type        string    `json:"type,omitempty"`
+		Size        int64     `json:"size,omitempty"`
+		Variants    []Variant `json:"variants,omitempty"`
+	}
+
+	Variant struct {
+		URL       string `json:"url"`
+		Type      string `json:"type,omitempty"`
+		Size      int64  `json:"size,omitempty"`
+		Width     int    `json:"width,omitempty"`
+		Height    int    `json:"height,omitempty"`
+		Bandwidth int    `json:"bandwidth,omitempty"`
+	}
+
+	Registry struct {
+		mu       sync.RWMutex
+		routes   []route
+		fallback []Resolver
+	}
+
+	route struct {
+		hosts    []string
+		resolver Resolver
+	}
+)
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Register(resolver Resolver, hosts ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.routes = append(r.routes, route{
+		hosts:    hosts,
+		resolver: resolver,
+	})
+}
+
+func (r *Registry) Fallback(resolvers ...Resolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.fallback = append(r.fallback, resolvers...)
+}
+
+func (r *Registry) Lookup(rawURL string) (Resolver, bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, route := range r.routes {
+		if MatchHost(parsed.Hostname(), route.hosts...) {
+			return route.resolver, true
+		}
+	}
+
+	return nil, false
+}
+
+func (r *Registry) Resolve(ctx context.Context, rawURL string, options Options) ([]Media, error) {
+	if resolver, ok := r.Lookup(rawURL); ok {
+		return resolver.Resolve(ctx, rawURL, options)
+	}
+
+	r.mu.RLock()
+	fallback := append([]Resolver(nil), r.fallback...)
+	r.mu.RUnlock()
+
+	var rejected error
+	for _, resolver := range fallback {
+		mediaList, err := resolver.Resolve(ctx, rawURL, options)
+		if errors.Is(err, urlpolicy.ErrRejected) {
+			rejected = err
+			continue
+		} else if errors.Is(err, ErrUnsupported) {
+			continue
+		}
+
+		return mediaList, err
+	}
+
+	if rejected != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupported, rejected)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupported, rawURL)
+}
+
+func MatchHost(host string, hosts ...string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+
+	return false
+}
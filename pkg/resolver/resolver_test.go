@@ -0,0 +1,305 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"github.com/alancesar/imgur-fetcher/pkg/resolver/testdata"
+	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"github.com/alancesar/imgur-fetcher/pkg/urlpolicy"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type (
+	fakeResolver struct {
+		name string
+		err  error
+	}
+)
+
+func (f fakeResolver) Resolve(_ context.Context, rawURL string, _ Options) ([]Media, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return []Media{{Provider: f.name, URL: rawURL}}, nil
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(fakeResolver{name: "imgur"}, "imgur.com", "imgur.io")
+	registry.Register(fakeResolver{name: "reddit"}, "reddit.com", "redd.it")
+	registry.Fallback(fakeResolver{name: "direct", err: ErrUnsupported}, fakeResolver{name: "opengraph"})
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "Should route hosts to their provider", url: "https://imgur.com/a/some-album", want: "imgur"},
+		{name: "Should route subdomains to their provider", url: "https://i.redd.it/some-image.jpg", want: "reddit"},
+		{name: "Should not match hosts that only end with a provider host", url: "https://notimgur.com/some-id", want: "opengraph"},
+		{name: "Should try fallbacks in order for unknown hosts", url: "https://example.com/some-page", want: "opengraph"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := registry.Resolve(context.Background(), tt.url, Options{})
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+
+			if len(got) != 1 || got[0].Provider != tt.want {
+				t.Errorf("Resolve() got = %+v, want provider %s", got, tt.want)
+			}
+		})
+	}
+
+	rejecting := NewRegistry()
+	rejecting.Fallback(fakeResolver{name: "direct", err: urlpolicy.ErrRejected}, fakeResolver{name: "opengraph", err: urlpolicy.ErrRejected})
+	if _, err := rejecting.Resolve(context.Background(), "https://example.com", Options{}); !errors.Is(err, ErrUnsupported) || !errors.Is(err, urlpolicy.ErrRejected) {
+		t.Errorf("Resolve() error = %v, want %v wrapping %v", err, ErrUnsupported, urlpolicy.ErrRejected)
+	}
+
+	empty := NewRegistry()
+	if _, err := empty.Resolve(context.Background(), "https://example.com", Options{}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Resolve() error = %v, wantErr %v", err, ErrUnsupported)
+	}
+}
+
+func TestDirect_Resolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/some-image.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Header().Set("Content-Length", "1024")
+		case "/no-head.mp4":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+
+			w.Header().Set("Content-Type", "video/mp4")
+			_, _ = w.Write([]byte("x"))
+		case "/redirect":
+			http.Redirect(w, r, "/some-image.jpg", http.StatusFound)
+		case "/some-page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		want    []Media
+		wantErr error
+	}{
+		{
+			name: "Should detect media by content type",
+			path: "/some-image.jpg",
+			want: []Media{{Provider: ProviderDirect, URL: server.URL + "/some-image.jpg", Type: "image/jpeg", Size: 1024}},
+		},
+		{
+			name: "Should fall back to GET when HEAD is not allowed",
+			path: "/no-head.mp4",
+			want: []Media{{Provider: ProviderDirect, URL: server.URL + "/no-head.mp4", Type: "video/mp4"}},
+		},
+		{
+			name: "Should return the URL after redirects",
+			path: "/redirect",
+			want: []Media{{Provider: ProviderDirect, URL: server.URL + "/some-image.jpg", Type: "image/jpeg", Size: 1024}},
+		},
+		{
+			name:    "Should not resolve html pages",
+			path:    "/some-page",
+			wantErr: ErrUnsupported,
+		},
+		{
+			name:    "Should return not found for missing files",
+			path:    "/missing.jpg",
+			wantErr: status.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDirect(server.Client()).Resolve(context.Background(), server.URL+tt.path, Options{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenGraph_Resolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages := map[string]string{
+			"/video": testdata.OpenGraphVideoPage,
+			"/image": testdata.OpenGraphImagePage,
+			"/plain": testdata.PlainPage,
+		}
+
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			w.Header().Set("Content-Type", "image/jpeg")
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		want    []Media
+		wantErr error
+	}{
+		{
+			name: "Should prefer og:video over og:image",
+			path: "/video",
+			want: []Media{
+				{Provider: ProviderOpenGraph, Title: "Some video title", URL: "https://cdn.example.com/some-video.mp4", Type: "video/mp4"},
+			},
+		},
+		{
+			name: "Should resolve relative og:image URLs and skip duplicates",
+			path: "/image",
+			want: []Media{
+				{Provider: ProviderOpenGraph, Title: "Some gallery", URL: "https://cdn.example.com/first.jpg", Type: "image/jpeg"},
+				{Provider: ProviderOpenGraph, Title: "Some gallery", URL: server.URL + "/second.png"},
+			},
+		},
+		{
+			name:    "Should not resolve pages without Open Graph media",
+			path:    "/plain",
+			wantErr: ErrUnsupported,
+		},
+		{
+			name:    "Should not resolve non html responses",
+			path:    "/some-image.jpg",
+			wantErr: ErrUnsupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewOpenGraph(server.Client()).Resolve(context.Background(), server.URL+tt.path, Options{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Apply(t *testing.T) {
+	gif := Media{
+		ID:   "gif",
+		URL:  "https://example.com/gif.gif",
+		Type: "image/gif",
+		Size: 30,
+		Variants: []Variant{
+			{URL: "https://example.com/gif.gif", Type: "image/gif", Size: 30},
+			{URL: "https://example.com/gif-small.mp4", Type: "video/mp4", Size: 5, Width: 320, Height: 240},
+			{URL: "https://example.com/gif-large.mp4", Type: "video/mp4", Size: 10, Width: 640, Height: 480},
+		},
+	}
+	stream := Media{
+		ID:   "stream",
+		URL:  "https://example.com/stream.m3u8",
+		Type: TypeHLS,
+		Variants: []Variant{
+			{URL: "https://example.com/stream.m3u8", Type: TypeHLS},
+			{URL: "https://example.com/stream.mp4", Type: "video/mp4", Size: 50},
+		},
+	}
+	clip := Media{
+		ID:   "clip",
+		URL:  "https://example.com/clip.gif",
+		Type: "image/gif",
+		Size: 8,
+		Variants: []Variant{
+			{URL: "https://example.com/clip.mp4", Type: "video/mp4", Size: 12, Width: 640, Height: 480},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		policy  string
+		maxSize int64
+		want    []string
+		wantIDs []string
+	}{
+		{
+			name:   "Should prefer the largest mp4 of gifs and keep streams",
+			policy: PolicyMP4,
+			want:   []string{"https://example.com/gif-large.mp4", "https://example.com/stream.m3u8", "https://example.com/clip.mp4"},
+		},
+		{
+			name:    "Should fall back to the next variant that fits the size cap",
+			policy:  PolicyMP4,
+			maxSize: 9,
+			want:    []string{"https://example.com/gif-small.mp4", "https://example.com/stream.m3u8", "https://example.com/clip.gif"},
+		},
+		{
+			name:   "Should keep the original files",
+			policy: PolicyOriginal,
+			want:   []string{"https://example.com/gif.gif", "https://example.com/stream.m3u8", "https://example.com/clip.gif"},
+		},
+		{
+			name:   "Should choose the smallest file with a known size",
+			policy: PolicySmallest,
+			want:   []string{"https://example.com/gif-small.mp4", "https://example.com/stream.mp4", "https://example.com/clip.gif"},
+		},
+		{
+			name:    "Should drop media over the size cap",
+			policy:  PolicySmallest,
+			maxSize: 20,
+			want:    []string{"https://example.com/gif-small.mp4", "https://example.com/clip.gif"},
+		},
+		{
+			name:    "Should emit every distinct variant with its own ID",
+			policy:  PolicyAll,
+			maxSize: 20,
+			want:    []string{"https://example.com/gif-small.mp4", "https://example.com/gif-large.mp4", "https://example.com/stream.m3u8", "https://example.com/clip.gif", "https://example.com/clip.mp4"},
+			wantIDs: []string{"gif-2", "gif-3", "stream", "clip", "clip-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParsePolicy(tt.policy, tt.maxSize)
+			if err != nil {
+				t.Fatalf("ParsePolicy() error = %v", err)
+			}
+
+			var got, gotIDs []string
+			for _, m := range policy.Apply([]Media{gif, stream, clip}) {
+				got = append(got, m.URL)
+				gotIDs = append(gotIDs, m.ID)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+
+			if tt.wantIDs != nil && !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("Apply() IDs = %v, want %v", gotIDs, tt.wantIDs)
+			}
+		})
+	}
+
+	if _, err := ParsePolicy("bogus", 0); !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("ParsePolicy() error = %v, want %v", err, ErrInvalidPolicy)
+	}
+}
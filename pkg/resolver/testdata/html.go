@@ -0,0 +1,37 @@
+package testdata
+
+const OpenGraphVideoPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Some page</title>
+  <meta property="og:title" content="Some video title">
+  <meta property="og:image" content="/images/some-poster.jpg">
+  <meta property="og:video" content="http://cdn.example.com/some-video.mp4">
+  <meta property="og:video:secure_url" content="https://cdn.example.com/some-video.mp4">
+  <meta property="og:video:type" content="video/mp4">
+  <meta property="og:video:width" content="1280">
+</head>
+<body>
+  <meta property="og:video" content="https://cdn.example.com/ignored.mp4">
+</body>
+</html>
+`
+
+const OpenGraphImagePage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta property="og:title" content="Some gallery">
+  <meta property="og:image" content="https://cdn.example.com/first.jpg">
+  <meta property="og:image:type" content="image/jpeg">
+  <meta property="og:image" content="/second.png">
+  <meta property="og:image:url" content="https://cdn.example.com/first.jpg">
+  <meta name="twitter:image" content="https://cdn.example.com/twitter.jpg">
+</head>
+</html>
+`
+
+const PlainPage = `<!DOCTYPE html>
+<html>
+<head><title>Nothing to see</title></head>
+</html>
+`
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type (
+	Local struct {
+		root string
+	}
+)
+
+func NewLocal(root string) *Local {
+	return &Local{
+		root: root,
+	}
+}
+
+func (l Local) Put(_ context.Context, key string, r io.Reader, _ Info) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(dir, "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+
+	if _, err := io.Copy(file, r); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Chmod(0o644); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), name)
+}
+
+func (l Local) Stat(_ context.Context, key string) (Info, error) {
+	name, err := l.path(key)
+	if err != nil {
+		return Info{}, err
+	}
+
+	stat, err := os.Stat(name)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && stat.IsDir()) {
+		return Info{}, ErrNotExist
+	} else if err != nil {
+		return Info{}, err
+	}
+
+	return localInfo(key, stat), nil
+}
+
+func (l Local) Get(ctx context.Context, key string) (io.ReadCloser, Info, error) {
+	info, err := l.Stat(ctx, key)
+	if err != nil {
+		return nil, Info{}, err
+	}
+
+	name, _ := l.path(key)
+	file, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, Info{}, ErrNotExist
+	} else if err != nil {
+		return nil, Info{}, err
+	}
+
+	return file, info, nil
+}
+
+func (l Local) Delete(_ context.Context, key string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); errors.Is(err, fs.ErrNotExist) {
+		return ErrNotExist
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (l Local) Link(ctx context.Context, src, dst string) error {
+	source, err := l.path(src)
+	if err != nil {
+		return err
+	}
+
+	target, err := l.path(dst)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	temporary := filepath.Join(filepath.Dir(target), "."+filepath.Base(target)+".link.tmp")
+	_ = os.Remove(temporary)
+	if err := os.Link(source, temporary); errors.Is(err, fs.ErrNotExist) {
+		return ErrNotExist
+	} else if err != nil {
+		reader, info, err := l.Get(ctx, src)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = reader.Close()
+		}()
+
+		return l.Put(ctx, dst, reader, info)
+	}
+
+	if err := os.Rename(temporary, target); err != nil {
+		_ = os.Remove(temporary)
+		return err
+	}
+
+	return nil
+}
+
+func (l Local) List(_ context.Context, prefix string) ([]Info, error) {
+	var infos []Info
+	err := filepath.WalkDir(l.root, func(name string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.root, name)
+		if err != nil {
+			return err
+		}
+
+		stat, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		infos = append(infos, localInfo(filepath.ToSlash(rel), stat))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Key < infos[j].Key
+	})
+
+	return filter(infos, prefix), nil
+}
+
+func (l Local) path(key string) (string, error) {
+	key, err := Key(key)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
+
+func localInfo(key string, stat fs.FileInfo) Info {
+	return Info{
+		Key:     key,
+		Size:    stat.Size(),
+		ModTime: stat.ModTime(),
+	}
+}
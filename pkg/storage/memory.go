@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	Memory struct {
+		mu      sync.RWMutex
+		objects map[string]memoryObject
+	}
+
+	memoryObject struct {
+		data []byte
+		info Info
+	}
+)
+
+func NewMemory() *Memory {
+	return &Memory{
+		objects: map[string]memoryObject{},
+	}
+}
+
+func (m *Memory) Put(_ context.Context, key string, r io.Reader, info Info) error {
+	key, err := Key(key)
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.objects[key] = memoryObject{
+		data: data,
+		info: Info{
+			Key:         key,
+			Size:        int64(len(data)),
+			ContentType: info.ContentType,
+			ModTime:     time.Now(),
+		},
+	}
+
+	return nil
+}
+
+func (m *Memory) Stat(_ context.Context, key string) (Info, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	object, exists := m.objects[key]
+	if !exists {
+		return Info{}, ErrNotExist
+	}
+
+	return object.info, nil
+}
+
+func (m *Memory) Get(_ context.Context, key string) (io.ReadCloser, Info, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	object, exists := m.objects[key]
+	if !exists {
+		return nil, Info{}, ErrNotExist
+	}
+
+	return io.NopCloser(bytes.NewReader(object.data)), object.info, nil
+}
+
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.objects[key]; !exists {
+		return ErrNotExist
+	}
+
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *Memory) Link(_ context.Context, src, dst string) error {
+	dst, err := Key(dst)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	object, exists := m.objects[src]
+	if !exists {
+		return ErrNotExist
+	}
+
+	object.info.Key = dst
+	m.objects[dst] = object
+	return nil
+}
+
+func (m *Memory) List(_ context.Context, prefix string) ([]Info, error) {
+	m.mu.RLock()
+	infos := make([]Info, 0, len(m.objects))
+	for _, object := range m.objects {
+		infos = append(infos, object.info)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Key < infos[j].Key
+	})
+
+	return filter(infos, prefix), nil
+}
@@ -0,0 +1,409 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm      = "AWS4-HMAC-SHA256"
+	s3Service        = "s3"
+	s3DateFormat     = "20060102"
+	s3DateTimeFormat = "20060102T150405Z"
+	s3EmptyHash      = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	s3Unsigned       = "UNSIGNED-PAYLOAD"
+)
+
+var (
+	ErrInvalidS3Config = errors.New("invalid S3 configuration")
+)
+
+type (
+	S3Options struct {
+		Endpoint        string
+		Region          string
+		Bucket          string
+		AccessKeyID     string
+		SecretAccessKey string
+		PathStyle       bool
+	}
+
+	S3 struct {
+		httpClient *http.Client
+		endpoint   *url.URL
+		options    S3Options
+		now        func() time.Time
+	}
+
+	s3ListResult struct {
+		Contents []struct {
+			Key          string    `xml:"Key"`
+			Size         int64     `xml:"Size"`
+			LastModified time.Time `xml:"LastModified"`
+		} `xml:"Contents"`
+		IsTruncated           bool   `xml:"IsTruncated"`
+		NextContinuationToken string `xml:"NextContinuationToken"`
+	}
+)
+
+func NewS3(httpClient *http.Client, options S3Options) (*S3, error) {
+	if options.Bucket == "" {
+		return nil, fmt.Errorf("%w: bucket is required", ErrInvalidS3Config)
+	}
+
+	if options.Region == "" {
+		options.Region = "us-east-1"
+	}
+
+	if options.Endpoint == "" {
+		options.Endpoint = "https://s3." + options.Region + ".amazonaws.com"
+	}
+
+	endpoint, err := url.Parse(options.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("%w: invalid endpoint %q", ErrInvalidS3Config, options.Endpoint)
+	}
+
+	return &S3{
+		httpClient: httpClient,
+		endpoint:   endpoint,
+		options:    options,
+		now:        time.Now,
+	}, nil
+}
+
+func (s S3) Put(ctx context.Context, key string, r io.Reader, info Info) error {
+	key, err := Key(key)
+	if err != nil {
+		return err
+	}
+
+	size := info.Size
+	if size < 0 {
+		spooled, n, err := spool(r)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = spooled.Close()
+			_ = os.Remove(spooled.Name())
+		}()
+
+		r, size = spooled, n
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, key, nil, r)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+
+	if info.ContentType != "" {
+		req.Header.Set("Content-Type", info.ContentType)
+	}
+
+	res, err := s.do(req, s3Unsigned)
+	if err != nil {
+		return err
+	}
+
+	return res.Body.Close()
+}
+
+func (s S3) Link(ctx context.Context, src, dst string) error {
+	dst, err := Key(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.Stat(ctx, src); err != nil {
+		return err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, dst, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Amz-Copy-Source", "/"+uriEncode(s.options.Bucket, true)+"/"+uriEncode(src, false))
+	res, err := s.do(req, s3EmptyHash)
+	if err != nil {
+		return err
+	}
+
+	return res.Body.Close()
+}
+
+func (s S3) Stat(ctx context.Context, key string) (Info, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return Info{}, err
+	}
+
+	res, err := s.do(req, s3EmptyHash)
+	if err != nil {
+		return Info{}, err
+	}
+
+	_ = res.Body.Close()
+	return responseInfo(key, res), nil
+}
+
+func (s S3) Get(ctx context.Context, key string) (io.ReadCloser, Info, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, Info{}, err
+	}
+
+	res, err := s.do(req, s3EmptyHash)
+	if err != nil {
+		return nil, Info{}, err
+	}
+
+	return res.Body, responseInfo(key, res), nil
+}
+
+func (s S3) Delete(ctx context.Context, key string) error {
+	if _, err := s.Stat(ctx, key); err != nil {
+		return err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.do(req, s3EmptyHash)
+	if err != nil {
+		return err
+	}
+
+	return res.Body.Close()
+}
+
+func (s S3) List(ctx context.Context, prefix string) ([]Info, error) {
+	var infos []Info
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		req, err := s.newRequest(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := s.do(req, s3EmptyHash)
+		if err != nil {
+			return nil, err
+		}
+
+		var result s3ListResult
+		err = xml.NewDecoder(res.Body).Decode(&result)
+		_ = res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode S3 listing: %w", err)
+		}
+
+		for _, content := range result.Contents {
+			infos = append(infos, Info{
+				Key:     content.Key,
+				Size:    content.Size,
+				ModTime: content.LastModified,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return filter(infos, prefix), nil
+		}
+
+		token = result.NextContinuationToken
+	}
+}
+
+func (s S3) newRequest(ctx context.Context, method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	target := *s.endpoint
+	escapedPath := strings.TrimSuffix(target.EscapedPath(), "/")
+	if s.options.PathStyle {
+		escapedPath += "/" + uriEncode(s.options.Bucket, true)
+	} else {
+		target.Host = s.options.Bucket + "." + target.Host
+	}
+
+	escapedPath += "/" + uriEncode(key, false)
+	target.RawQuery = canonicalQuery(query)
+
+	rawURL := target.Scheme + "://" + target.Host + escapedPath
+	if target.RawQuery != "" {
+		rawURL += "?" + target.RawQuery
+	}
+
+	return http.NewRequestWithContext(ctx, method, rawURL, body)
+}
+
+func (s S3) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash)
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		_ = res.Body.Close()
+		return nil, ErrNotExist
+	} else if res.StatusCode >= http.StatusBadRequest {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("%w: %d (%s): %s", status.ErrBadStatus, res.StatusCode, res.Status, strings.TrimSpace(string(message)))
+	}
+
+	return res, nil
+}
+
+func (s S3) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format(s3DateTimeFormat)
+	scope := strings.Join([]string{now.Format(s3DateFormat), s.options.Region, s3Service, "aws4_request"}, "/")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "range" || name == "content-type" || name == "content-md5" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, hex.EncodeToString(hashed[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.options.SecretAccessKey), now.Format(s3DateFormat))
+	key = hmacSHA256(key, s.options.Region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.options.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func responseInfo(key string, res *http.Response) Info {
+	info := Info{
+		Key:         key,
+		Size:        res.ContentLength,
+		ContentType: res.Header.Get("Content-Type"),
+	}
+
+	if modTime, err := http.ParseTime(res.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = modTime
+	}
+
+	if size, err := strconv.ParseInt(res.Header.Get("Content-Length"), 10, 64); err == nil {
+		info.Size = size
+	}
+
+	return info
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			pairs = append(pairs, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func uriEncode(value string, encodeSlash bool) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', b == '-', b == '.', b == '_', b == '~':
+			builder.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			builder.WriteByte(b)
+		default:
+			builder.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+
+	return builder.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func spool(r io.Reader) (*os.File, int64, error) {
+	file, err := os.CreateTemp("", "imgur-fetcher-*.tmp")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	n, err := io.Copy(file, r)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, 0, err
+	}
+
+	return file, n, nil
+}
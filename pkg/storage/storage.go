@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/media"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	DefaultLayout = "{{.Parent}}/{{.Name}}"
+)
+
+var (
+	ErrNotExist   = errors.New("object does not exist")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+type (
+	Backend interface {
+		Put(ctx context.Context, key string, r io.Reader, info Info) error
+		Stat(ctx context.Context, key string) (Info, error)
+		Get(ctx context.Context, key string) (io.ReadCloser, Info, error)
+		Delete(ctx context.Context, key string) error
+		List(ctx context.Context, prefix string) ([]Info, error)
+	}
+
+	Linker interface {
+		Link(ctx context.Context, src, dst string) error
+	}
+
+	Info struct {
+		Key         string
+		Size        int64
+		ContentType string
+		ModTime     time.Time
+	}
+
+	Item struct {
+		Parent string
+		Name   string
+		ID     string
+		Ext    string
+		Host   string
+	}
+
+	Layout struct {
+		tmpl *template.Template
+	}
+)
+
+func Key(elem ...string) (string, error) {
+	key := path.Join(elem...)
+	if key == "" || key == "." || strings.HasPrefix(key, "/") || key == ".." || strings.HasPrefix(key, "../") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, strings.Join(elem, "/"))
+	}
+
+	return key, nil
+}
+
+func Link(ctx context.Context, backend Backend, src, dst string) error {
+	if linker, ok := backend.(Linker); ok {
+		return linker.Link(ctx, src, dst)
+	}
+
+	reader, info, err := backend.Get(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	info.Key = dst
+	return backend.Put(ctx, dst, reader, info)
+}
+
+func NewItem(m media.Media) (Item, error) {
+	parsed, err := url.Parse(m.URL)
+	if err != nil {
+		return Item{}, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	name := path.Base(parsed.Path)
+	if name == "/" || strings.HasPrefix(name, ".") {
+		return Item{}, fmt.Errorf("%w: no file name in %s", ErrInvalidKey, m.URL)
+	}
+
+	ext := path.Ext(name)
+	return Item{
+		Parent: path.Join(m.Parent...),
+		Name:   name,
+		ID:     strings.TrimSuffix(name, ext),
+		Ext:    ext,
+		Host:   parsed.Hostname(),
+	}, nil
+}
+
+func NewLayout(text string) (*Layout, error) {
+	if text == "" {
+		text = DefaultLayout
+	}
+
+	tmpl, err := template.New("layout").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse storage layout: %w", err)
+	}
+
+	return &Layout{
+		tmpl: tmpl,
+	}, nil
+}
+
+func (l *Layout) Key(item Item) (string, error) {
+	var builder strings.Builder
+	if err := l.tmpl.Execute(&builder, item); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	return Key(builder.String())
+}
+
+func filter(infos []Info, prefix string) []Info {
+	filtered := make([]Info, 0, len(infos))
+	for _, info := range infos {
+		if strings.HasPrefix(info.Key, prefix) && !isTemporary(info.Key) {
+			filtered = append(filtered, info)
+		}
+	}
+
+	return filtered
+}
+
+func isTemporary(key string) bool {
+	name := path.Base(key)
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".tmp")
+}
@@ -0,0 +1,373 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/status"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	propfindBody = `<?xml version="1.0" encoding="utf-8"?><propfind xmlns="DAV:"><prop><resourcetype/><getcontentlength/><getcontenttype/><getlastmodified/></prop></propfind>`
+)
+
+var (
+	ErrInvalidWebDAVConfig = errors.New("invalid WebDAV configuration")
+)
+
+type (
+	WebDAV struct {
+		httpClient *http.Client
+		base       *url.URL
+		username   string
+		password   string
+	}
+
+	davMultistatus struct {
+		Responses []davResponse `xml:"DAV: response"`
+	}
+
+	davResponse struct {
+		Href      string        `xml:"DAV: href"`
+		Propstats []davPropstat `xml:"DAV: propstat"`
+	}
+
+	davPropstat struct {
+		Status string  `xml:"DAV: status"`
+		Prop   davProp `xml:"DAV: prop"`
+	}
+
+	davProp struct {
+		ResourceType struct {
+			Collection *struct{} `xml:"DAV: collection"`
+		} `xml:"DAV: resourcetype"`
+		ContentLength int64  `xml:"DAV: getcontentlength"`
+		ContentType   string `xml:"DAV: getcontenttype"`
+		LastModified  string `xml:"DAV: getlastmodified"`
+	}
+
+	davEntry struct {
+		info       Info
+		collection bool
+	}
+)
+
+func NewWebDAV(httpClient *http.Client, rawURL, username, password string) (*WebDAV, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("%w: invalid URL %q", ErrInvalidWebDAVConfig, rawURL)
+	}
+
+	base.Path = strings.TrimSuffix(base.Path, "/") + "/"
+	base.RawPath = ""
+	return &WebDAV{
+		httpClient: httpClient,
+		base:       base,
+		username:   username,
+		password:   password,
+	}, nil
+}
+
+func (w WebDAV) Put(ctx context.Context, key string, r io.Reader, info Info) error {
+	key, err := Key(key)
+	if err != nil {
+		return err
+	}
+
+	if err := w.mkcol(ctx, path.Dir(key)); err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+
+	temporary := path.Join(path.Dir(key), "."+path.Base(key)+"."+hex.EncodeToString(suffix)+".tmp")
+	req, err := w.newRequest(ctx, http.MethodPut, temporary, r)
+	if err != nil {
+		return err
+	}
+
+	if info.Size >= 0 {
+		req.ContentLength = info.Size
+	}
+
+	if info.ContentType != "" {
+		req.Header.Set("Content-Type", info.ContentType)
+	}
+
+	if err := w.do(req); err != nil {
+		return err
+	}
+
+	move, err := w.newRequest(ctx, "MOVE", temporary, nil)
+	if err != nil {
+		return err
+	}
+
+	move.Header.Set("Destination", w.url(key).String())
+	move.Header.Set("Overwrite", "T")
+	if err := w.do(move); err != nil {
+		if cleanup, err := w.newRequest(context.Background(), http.MethodDelete, temporary, nil); err == nil {
+			_ = w.do(cleanup)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (w WebDAV) Link(ctx context.Context, src, dst string) error {
+	dst, err := Key(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Stat(ctx, src); err != nil {
+		return err
+	}
+
+	if err := w.mkcol(ctx, path.Dir(dst)); err != nil {
+		return err
+	}
+
+	req, err := w.newRequest(ctx, "COPY", src, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Destination", w.url(dst).String())
+	req.Header.Set("Overwrite", "T")
+	return w.do(req)
+}
+
+func (w WebDAV) Stat(ctx context.Context, key string) (Info, error) {
+	entries, err := w.propfind(ctx, key, "0")
+	if err != nil {
+		return Info{}, err
+	}
+
+	if len(entries) == 0 || entries[0].collection {
+		return Info{}, ErrNotExist
+	}
+
+	return entries[0].info, nil
+}
+
+func (w WebDAV) Get(ctx context.Context, key string) (io.ReadCloser, Info, error) {
+	req, err := w.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, Info{}, err
+	}
+
+	res, err := w.send(req)
+	if err != nil {
+		return nil, Info{}, err
+	}
+
+	return res.Body, responseInfo(key, res), nil
+}
+
+func (w WebDAV) Delete(ctx context.Context, key string) error {
+	if _, err := w.Stat(ctx, key); err != nil {
+		return err
+	}
+
+	req, err := w.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	return w.do(req)
+}
+
+func (w WebDAV) List(ctx context.Context, prefix string) ([]Info, error) {
+	root := ""
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		root = prefix[:i]
+	}
+
+	var infos []Info
+	pending := []string{root}
+	for len(pending) > 0 {
+		dir := pending[0]
+		pending = pending[1:]
+
+		entries, err := w.propfind(ctx, dir, "1")
+		if errors.Is(err, ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.info.Key == dir || entry.info.Key == "" {
+				continue
+			}
+
+			if entry.collection {
+				pending = append(pending, entry.info.Key)
+				continue
+			}
+
+			infos = append(infos, entry.info)
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Key < infos[j].Key
+	})
+
+	return filter(infos, prefix), nil
+}
+
+func (w WebDAV) mkcol(ctx context.Context, dir string) error {
+	if dir == "." || dir == "" {
+		return nil
+	}
+
+	current := ""
+	for _, segment := range strings.Split(dir, "/") {
+		current = path.Join(current, segment)
+		req, err := w.newRequest(ctx, "MKCOL", current+"/", nil)
+		if err != nil {
+			return err
+		}
+
+		res, err := w.httpClient.Do(w.authorize(req))
+		if err != nil {
+			return err
+		}
+
+		_ = res.Body.Close()
+		if res.StatusCode >= http.StatusBadRequest && res.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("%w: %d (%s): MKCOL %s", status.ErrBadStatus, res.StatusCode, res.Status, current)
+		}
+	}
+
+	return nil
+}
+
+func (w WebDAV) propfind(ctx context.Context, key, depth string) ([]davEntry, error) {
+	req, err := w.newRequest(ctx, "PROPFIND", key, strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Depth", depth)
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	res, err := w.send(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	var multistatus davMultistatus
+	if err := xml.NewDecoder(res.Body).Decode(&multistatus); err != nil {
+		return nil, fmt.Errorf("failed to decode WebDAV response: %w", err)
+	}
+
+	entries := make([]davEntry, 0, len(multistatus.Responses))
+	for _, response := range multistatus.Responses {
+		entry, ok := w.entry(response)
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+func (w WebDAV) entry(response davResponse) (davEntry, bool) {
+	href, err := url.Parse(response.Href)
+	if err != nil {
+		return davEntry{}, false
+	}
+
+	key := strings.Trim(strings.TrimPrefix(href.Path, w.base.Path), "/")
+	if href.Path+"/" == w.base.Path {
+		key = ""
+	}
+
+	for _, propstat := range response.Propstats {
+		if !strings.Contains(propstat.Status, " 200 ") {
+			continue
+		}
+
+		info := Info{
+			Key:         key,
+			Size:        propstat.Prop.ContentLength,
+			ContentType: propstat.Prop.ContentType,
+		}
+
+		if modTime, err := http.ParseTime(propstat.Prop.LastModified); err == nil {
+			info.ModTime = modTime
+		}
+
+		return davEntry{
+			info:       info,
+			collection: propstat.Prop.ResourceType.Collection != nil,
+		}, true
+	}
+
+	return davEntry{}, false
+}
+
+func (w WebDAV) url(key string) *url.URL {
+	target := *w.base
+	target.Path = w.base.Path + key
+	target.RawPath = ""
+	return &target
+}
+
+func (w WebDAV) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, method, w.url(key).String(), body)
+}
+
+func (w WebDAV) authorize(req *http.Request) *http.Request {
+	if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	return req
+}
+
+func (w WebDAV) send(req *http.Request) (*http.Response, error) {
+	res, err := w.httpClient.Do(w.authorize(req))
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		_ = res.Body.Close()
+		return nil, ErrNotExist
+	} else if res.StatusCode >= http.StatusBadRequest {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("%w: %d (%s): %s %s", status.ErrBadStatus, res.StatusCode, res.Status, req.Method, req.URL.Path)
+	}
+
+	return res, nil
+}
+
+func (w WebDAV) do(req *http.Request) error {
+	res, err := w.send(req)
+	if err != nil {
+		return err
+	}
+
+	return res.Body.Close()
+}
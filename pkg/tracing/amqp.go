@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	AMQPCarrier amqp.Table
+)
+
+func (c AMQPCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+func (c AMQPCarrier) Set(key string, value string) {
+	c[key] = value
+}
+
+func (c AMQPCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+func Inject(ctx context.Context, headers amqp.Table) amqp.Table {
+	if headers == nil {
+		headers = amqp.Table{}
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, AMQPCarrier(headers))
+	return headers
+}
+
+func Extract(ctx context.Context, headers amqp.Table) context.Context {
+	if headers == nil {
+		return ctx
+	}
+
+	return otel.GetTextMapPropagator().Extract(ctx, AMQPCarrier(headers))
+}
+
+func StartPublish(ctx context.Context, exchange, key string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, exchange+"."+key+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			semconv.MessagingOperationPublish,
+			semconv.MessagingDestinationName(exchange),
+			semconv.MessagingRabbitmqDestinationRoutingKey(key),
+		),
+	)
+}
+
+func StartConsume(ctx context.Context, delivery amqp.Delivery) (context.Context, trace.Span) {
+	ctx = Extract(ctx, delivery.Headers)
+	return Tracer().Start(ctx, delivery.Exchange+"."+delivery.RoutingKey+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			semconv.MessagingOperationDeliver,
+			semconv.MessagingDestinationName(delivery.Exchange),
+			semconv.MessagingRabbitmqDestinationRoutingKey(delivery.RoutingKey),
+			attribute.Int64("messaging.rabbitmq.delivery_tag", int64(delivery.DeliveryTag)),
+		),
+	)
+}
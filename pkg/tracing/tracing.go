@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	TracerName = "github.com/alancesar/imgur-fetcher"
+)
+
+func Setup(ctx context.Context, serviceName, endpoint string) (*sdktrace.TracerProvider, error) {
+	var options []sdktrace.TracerProviderOption
+	if endpoint != "" {
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+		}
+
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := newProvider(serviceName, options...)
+	register(provider)
+	return provider, nil
+}
+
+func NewInMemory(serviceName string) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := newProvider(serviceName, sdktrace.WithSyncer(exporter))
+	register(provider)
+	return provider, exporter
+}
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+
+	return spanContext.TraceID().String()
+}
+
+func newProvider(serviceName string, options ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	res := resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	)
+
+	return sdktrace.NewTracerProvider(append(options, sdktrace.WithResource(res))...)
+}
+
+func register(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
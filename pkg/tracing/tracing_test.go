@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"context"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"testing"
+)
+
+func TestAMQPPropagation(t *testing.T) {
+	provider, exporter := NewInMemory("test")
+	defer func() {
+		_ = provider.Shutdown(context.Background())
+	}()
+
+	ctx, publishSpan := StartPublish(context.Background(), "fetcher", "imgur")
+	headers := Inject(ctx, nil)
+	publishSpan.End()
+
+	if _, ok := headers["traceparent"]; !ok {
+		t.Fatalf("Inject() headers = %v, want traceparent", headers)
+	}
+
+	consumeCtx, consumeSpan := StartConsume(context.Background(), amqp.Delivery{
+		Headers:    headers,
+		Exchange:   "fetcher",
+		RoutingKey: "imgur",
+	})
+	consumeSpan.End()
+
+	if got, want := TraceID(consumeCtx), TraceID(ctx); got != want {
+		t.Errorf("TraceID() = %v, want %v", got, want)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("GetSpans() = %d spans, want %d", len(spans), 2)
+	}
+
+	if spans[1].Parent.SpanID() != spans[0].SpanContext.SpanID() {
+		t.Errorf("consumer span parent = %v, want %v", spans[1].Parent.SpanID(), spans[0].SpanContext.SpanID())
+	}
+}
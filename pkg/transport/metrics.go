@@ -0,0 +1,28 @@
+package transport
+
+import (
+	"github.com/alancesar/imgur-fetcher/pkg/metrics"
+	"net/http"
+	"time"
+)
+
+type (
+	MetricsRoundTripper struct {
+		metrics *metrics.Metrics
+		next    http.RoundTripper
+	}
+)
+
+func NewMetricsRoundTripper(m *metrics.Metrics, next http.RoundTripper) http.RoundTripper {
+	return &MetricsRoundTripper{
+		metrics: m,
+		next:    next,
+	}
+}
+
+func (m MetricsRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := m.next.RoundTrip(r)
+	m.metrics.ObserveClientRequest(r, res, err, time.Since(start))
+	return res, err
+}
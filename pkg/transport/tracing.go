@@ -0,0 +1,52 @@
+package transport
+
+import (
+	"github.com/alancesar/imgur-fetcher/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+type (
+	TracingRoundTripper struct {
+		next http.RoundTripper
+	}
+)
+
+func NewTracingRoundTripper(next http.RoundTripper) http.RoundTripper {
+	return &TracingRoundTripper{
+		next: next,
+	}
+}
+
+func (t TracingRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx, span := tracing.Tracer().Start(r.Context(), r.Method+" "+r.URL.Host,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.ServerAddress(r.URL.Hostname()),
+			semconv.URLFull(r.URL.String()),
+		),
+	)
+	defer span.End()
+
+	newRequest := cloneRequest(r.WithContext(ctx))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(newRequest.Header))
+
+	res, err := t.next.RoundTrip(newRequest)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return res, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(res.StatusCode))
+	if res.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(res.StatusCode))
+	}
+
+	return res, nil
+}
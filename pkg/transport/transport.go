@@ -3,6 +3,7 @@ package transport
 import (
 	"context"
 	"net/http"
+	"time"
 )
 
 type (
@@ -12,6 +13,10 @@ type (
 		Log(req *http.Request, res *http.Response) error
 	}
 
+	Observer interface {
+		Observe(req *http.Request, res *http.Response, err error, elapsed time.Duration)
+	}
+
 	UserAgentRoundTripper struct {
 		userAgent string
 		next      http.RoundTripper
@@ -22,6 +27,11 @@ type (
 		authorization string
 		next          http.RoundTripper
 	}
+
+	ObserverRoundTripper struct {
+		observers []Observer
+		next      http.RoundTripper
+	}
 )
 
 func NewUserAgentRoundTripper(userAgent string, next http.RoundTripper) http.RoundTripper {
@@ -38,6 +48,13 @@ func NewAuthorizationRoundTripper(provider TokenProvider, next http.RoundTripper
 	}
 }
 
+func NewObserverRoundTripper(next http.RoundTripper, observers ...Observer) http.RoundTripper {
+	return &ObserverRoundTripper{
+		observers: observers,
+		next:      next,
+	}
+}
+
 func (a UserAgentRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
 	defer closeBody(r)
 
@@ -63,6 +80,18 @@ func (p *AuthorizationRoundTripper) RoundTrip(r *http.Request) (*http.Response,
 	return p.next.RoundTrip(newRequest)
 }
 
+func (o ObserverRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := o.next.RoundTrip(r)
+	elapsed := time.Since(start)
+
+	for _, observer := range o.observers {
+		observer.Observe(r, res, err, elapsed)
+	}
+
+	return res, err
+}
+
 func cloneRequest(request *http.Request) *http.Request {
 	newRequest := new(http.Request)
 	*newRequest = *request
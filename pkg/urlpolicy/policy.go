@@ -0,0 +1,204 @@
+package urlpolicy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultMaxRedirects = 5
+)
+
+var (
+	ErrRejected = errors.New("url rejected by policy")
+
+	AnyHost = "*"
+
+	DefaultAllowedSchemes = []string{"https", "http"}
+	DefaultAllowedHosts   = []string{"imgur.com", "imgur.io", "redd.it", "reddit.com"}
+
+	blockedPrefixes = []netip.Prefix{
+		netip.MustParsePrefix("0.0.0.0/8"),
+		netip.MustParsePrefix("100.64.0.0/10"),
+		netip.MustParsePrefix("192.0.0.0/24"),
+		netip.MustParsePrefix("198.18.0.0/15"),
+		netip.MustParsePrefix("64:ff9b::/96"),
+	}
+)
+
+type (
+	Policy struct {
+		AllowedSchemes []string
+		AllowedHosts   []string
+		MaxRedirects   int
+	}
+
+	RoundTripper struct {
+		policy Policy
+		next   http.RoundTripper
+	}
+)
+
+func Default() Policy {
+	return Policy{
+		AllowedSchemes: DefaultAllowedSchemes,
+		AllowedHosts:   DefaultAllowedHosts,
+		MaxRedirects:   defaultMaxRedirects,
+	}
+}
+
+func (p Policy) Check(u *url.URL) error {
+	if u == nil || !u.IsAbs() {
+		return fmt.Errorf("%w: url must be absolute", ErrRejected)
+	}
+
+	if !p.schemeAllowed(u.Scheme) {
+		return fmt.Errorf("%w: scheme %q is not allowed", ErrRejected, u.Scheme)
+	}
+
+	if u.User != nil {
+		return fmt.Errorf("%w: credentials are not allowed", ErrRejected)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return fmt.Errorf("%w: host is required", ErrRejected)
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if err := checkAddr(addr); err != nil {
+			return err
+		}
+	}
+
+	if !p.hostAllowed(host) {
+		return fmt.Errorf("%w: host %q is not allowed", ErrRejected, host)
+	}
+
+	return nil
+}
+
+func (p Policy) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > p.maxRedirects() {
+		return fmt.Errorf("%w: stopped after %d redirects", ErrRejected, p.maxRedirects())
+	}
+
+	return p.Check(req.URL)
+}
+
+func (p Policy) Transport() http.RoundTripper {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   control,
+	}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = nil
+	base.DialContext = dialer.DialContext
+
+	return &RoundTripper{
+		policy: p,
+		next:   base,
+	}
+}
+
+func (p Policy) Client(wrap func(http.RoundTripper) http.RoundTripper) *http.Client {
+	rt := p.Transport()
+	if wrap != nil {
+		rt = wrap(rt)
+	}
+
+	return &http.Client{
+		Transport:     rt,
+		CheckRedirect: p.CheckRedirect,
+	}
+}
+
+func (rt RoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	if err := rt.policy.Check(r.URL); err != nil {
+		if r.Body != nil {
+			_ = r.Body.Close()
+		}
+
+		return nil, err
+	}
+
+	return rt.next.RoundTrip(r)
+}
+
+func (p Policy) schemeAllowed(scheme string) bool {
+	schemes := p.AllowedSchemes
+	if len(schemes) == 0 {
+		schemes = DefaultAllowedSchemes
+	}
+
+	for _, s := range schemes {
+		if strings.EqualFold(s, scheme) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p Policy) hostAllowed(host string) bool {
+	for _, allowed := range p.AllowedHosts {
+		if allowed == AnyHost {
+			return true
+		}
+
+		allowed = strings.ToLower(strings.TrimPrefix(allowed, "*."))
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p Policy) maxRedirects() int {
+	if p.MaxRedirects <= 0 {
+		return defaultMaxRedirects
+	}
+
+	return p.MaxRedirects
+}
+
+func control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+
+	return checkAddr(addr)
+}
+
+func checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return fmt.Errorf("%w: address %s is not public", ErrRejected, addr)
+	}
+
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("%w: address %s is not public", ErrRejected, addr)
+		}
+	}
+
+	return nil
+}
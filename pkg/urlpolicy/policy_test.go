@@ -0,0 +1,128 @@
+package urlpolicy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		rawURL  string
+		wantErr bool
+	}{
+		{
+			name:    "Should allow imgur URLs by default",
+			policy:  Default(),
+			rawURL:  "https://i.imgur.com/some-image.jpg",
+			wantErr: false,
+		},
+		{
+			name:    "Should reject hosts outside the allowed list",
+			policy:  Default(),
+			rawURL:  "https://example.com/some-image.jpg",
+			wantErr: true,
+		},
+		{
+			name:    "Should allow any public host with the wildcard",
+			policy:  Policy{AllowedHosts: []string{AnyHost}},
+			rawURL:  "https://example.com/some-image.jpg",
+			wantErr: false,
+		},
+		{
+			name:    "Should still reject private addresses with the wildcard",
+			policy:  Policy{AllowedHosts: []string{AnyHost}},
+			rawURL:  "http://169.254.169.254/latest/meta-data",
+			wantErr: true,
+		},
+		{
+			name:    "Should reject hosts that only look like an allowed one",
+			policy:  Default(),
+			rawURL:  "https://evilimgur.com/some-image.jpg",
+			wantErr: true,
+		},
+		{
+			name:    "Should reject schemes outside the allowed list",
+			policy:  Default(),
+			rawURL:  "file:///etc/passwd",
+			wantErr: true,
+		},
+		{
+			name:    "Should reject relative URLs",
+			policy:  Default(),
+			rawURL:  "/some-image.jpg",
+			wantErr: true,
+		},
+		{
+			name: "Should reject link-local addresses even if allowed by host",
+			policy: Policy{
+				AllowedHosts: []string{"169.254.169.254"},
+			},
+			rawURL:  "http://169.254.169.254/latest/meta-data",
+			wantErr: true,
+		},
+		{
+			name: "Should reject private addresses even if allowed by host",
+			policy: Policy{
+				AllowedHosts: []string{"10.0.0.1"},
+			},
+			rawURL:  "http://10.0.0.1/",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse(tt.rawURL)
+			err := tt.policy.Check(u)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrRejected) {
+				t.Errorf("Check() error = %v, want it to wrap %v", err, ErrRejected)
+			}
+		})
+	}
+}
+
+func TestPolicy_CheckRedirect(t *testing.T) {
+	policy := Policy{
+		AllowedHosts: DefaultAllowedHosts,
+		MaxRedirects: 2,
+	}
+
+	req, _ := http.NewRequest(http.MethodHead, "https://imgur.com/some-image", nil)
+	if err := policy.CheckRedirect(req, make([]*http.Request, 2)); err != nil {
+		t.Errorf("CheckRedirect() error = %v, wantErr %v", err, false)
+	}
+
+	if err := policy.CheckRedirect(req, make([]*http.Request, 3)); !errors.Is(err, ErrRejected) {
+		t.Errorf("CheckRedirect() error = %v, wantErr %v", err, ErrRejected)
+	}
+
+	req, _ = http.NewRequest(http.MethodHead, "http://169.254.169.254/", nil)
+	if err := policy.CheckRedirect(req, make([]*http.Request, 1)); !errors.Is(err, ErrRejected) {
+		t.Errorf("CheckRedirect() error = %v, wantErr %v", err, ErrRejected)
+	}
+}
+
+func TestPolicy_Client(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	policy := Policy{
+		AllowedHosts: []string{"localhost"},
+	}
+
+	port := server.URL[strings.LastIndex(server.URL, ":"):]
+	_, err := policy.Client(nil).Head("http://localhost" + port)
+	if !errors.Is(err, ErrRejected) {
+		t.Errorf("Head() error = %v, want %v after resolving to loopback", err, ErrRejected)
+	}
+}
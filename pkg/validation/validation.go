@@ -0,0 +1,233 @@
+package validation
+
+import (
+	"fmt"
+	"github.com/alancesar/imgur-fetcher/pkg/media"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	CodeRequired = "required"
+	CodeInvalid  = "invalid"
+	CodeTooLong  = "too_long"
+
+	MaxURLLength      = 2048
+	MaxParentSegments = 8
+	MaxSegmentLength  = 64
+)
+
+var (
+	segmentPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*$`)
+
+	trackingParams = map[string]struct{}{
+		"fbclid":  {},
+		"gclid":   {},
+		"dclid":   {},
+		"msclkid": {},
+		"igshid":  {},
+		"mc_cid":  {},
+		"mc_eid":  {},
+		"ref":     {},
+		"ref_src": {},
+		"si":      {},
+		"_ga":     {},
+	}
+
+	imgurHosts = map[string]struct{}{
+		"imgur.com":     {},
+		"www.imgur.com": {},
+		"m.imgur.com":   {},
+		"i.imgur.com":   {},
+		"imgur.io":      {},
+		"i.imgur.io":    {},
+	}
+)
+
+type (
+	FieldError struct {
+		Field   string `json:"field"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	Errors []FieldError
+)
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Field + ": " + fe.Message
+	}
+
+	return "invalid request: " + strings.Join(messages, "; ")
+}
+
+func Media(m media.Media) (media.Media, error) {
+	var errs Errors
+
+	normalized, err := NormalizeURL(m.URL)
+	if err != nil {
+		errs = append(errs, AsFieldErrors("url", err)...)
+	}
+
+	parent, err := SanitizeParent(m.Parent)
+	if err != nil {
+		errs = append(errs, AsFieldErrors("parent", err)...)
+	}
+
+	if m.Size < 0 {
+		errs = append(errs, FieldError{Field: "size", Code: CodeInvalid, Message: "size must not be negative"})
+	}
+
+	if len(errs) > 0 {
+		return media.Media{}, errs
+	}
+
+	m.URL = normalized
+	m.Parent = parent
+	return m, nil
+}
+
+func NormalizeURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", Errors{{Code: CodeRequired, Message: "url is required"}}
+	}
+
+	if len(rawURL) > MaxURLLength {
+		return "", Errors{{Code: CodeTooLong, Message: fmt.Sprintf("url must have at most %d characters", MaxURLLength)}}
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", Errors{{Code: CodeInvalid, Message: "url could not be parsed"}}
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if !u.IsAbs() || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", Errors{{Code: CodeInvalid, Message: "url must be an absolute http or https url"}}
+	}
+
+	if u.User != nil {
+		return "", Errors{{Code: CodeInvalid, Message: "url must not carry credentials"}}
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if port := u.Port(); port != "" && !isDefaultPort(u.Scheme, port) {
+		host = host + ":" + port
+	}
+
+	u.Host = host
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	if _, ok := imgurHosts[u.Hostname()]; ok {
+		return canonicalImgurURL(u).String(), nil
+	}
+
+	query := u.Query()
+	for param := range query {
+		if isTrackingParam(param) {
+			query.Del(param)
+		}
+	}
+
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func SanitizeParent(parent []string) ([]string, error) {
+	if len(parent) > MaxParentSegments {
+		return nil, Errors{{Code: CodeTooLong, Message: fmt.Sprintf("parent must have at most %d segments", MaxParentSegments)}}
+	}
+
+	var errs Errors
+	sanitized := make([]string, 0, len(parent))
+	for i, segment := range parent {
+		field := fmt.Sprintf("[%d]", i)
+		segment = strings.TrimSpace(segment)
+		switch {
+		case segment == "":
+			errs = append(errs, FieldError{Field: field, Code: CodeRequired, Message: "segment must not be empty"})
+		case len(segment) > MaxSegmentLength:
+			errs = append(errs, FieldError{Field: field, Code: CodeTooLong, Message: fmt.Sprintf("segment must have at most %d characters", MaxSegmentLength)})
+		case !segmentPattern.MatchString(segment):
+			errs = append(errs, FieldError{Field: field, Code: CodeInvalid, Message: "segment must only contain letters, digits, '.', '_' and '-' and must not start with '.' or '-'"})
+		default:
+			sanitized = append(sanitized, segment)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return sanitized, nil
+}
+
+func canonicalImgurURL(u *url.URL) *url.URL {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	canonical := &url.URL{
+		Scheme: "https",
+		Host:   "imgur.com",
+	}
+
+	switch {
+	case len(segments) == 2 && (segments[0] == "a" || segments[0] == "gallery"):
+		canonical.Path = "/" + segments[0] + "/" + slugID(segments[1])
+	case len(segments) >= 2 && segments[0] == "user" && segments[1] != "":
+		canonical.Path = "/user/" + segments[1]
+	case len(segments) == 1 && segments[0] != "":
+		canonical.Path = "/" + withoutExtension(segments[0])
+	default:
+		canonical.Path = u.Path
+		canonical.RawPath = u.RawPath
+	}
+
+	return canonical
+}
+
+func slugID(segment string) string {
+	segment = withoutExtension(segment)
+	if i := strings.LastIndex(segment, "-"); i >= 0 {
+		return segment[i+1:]
+	}
+
+	return segment
+}
+
+func withoutExtension(segment string) string {
+	segment, _, _ = strings.Cut(segment, ".")
+	return segment
+}
+
+func isTrackingParam(param string) bool {
+	param = strings.ToLower(param)
+	if strings.HasPrefix(param, "utm_") {
+		return true
+	}
+
+	_, ok := trackingParams[param]
+	return ok
+}
+
+func isDefaultPort(scheme, port string) bool {
+	return (scheme == "http" && port == "80") || (scheme == "https" && port == "443")
+}
+
+func AsFieldErrors(field string, err error) Errors {
+	errs, ok := err.(Errors)
+	if !ok {
+		return Errors{{Field: field, Code: CodeInvalid, Message: err.Error()}}
+	}
+
+	prefixed := make(Errors, len(errs))
+	for i, fe := range errs {
+		fe.Field = field + fe.Field
+		prefixed[i] = fe
+	}
+
+	return prefixed
+}
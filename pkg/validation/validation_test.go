@@ -0,0 +1,135 @@
+package validation
+
+import (
+	"github.com/alancesar/imgur-fetcher/pkg/media"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawURL  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "Should strip tracking parameters and fragments",
+			rawURL:  "HTTPS://Example.com:443/some/path?utm_source=foo&b=2&a=1&fbclid=bar#top",
+			want:    "https://example.com/some/path?a=1&b=2",
+			wantErr: false,
+		},
+		{
+			name:    "Should canonicalize direct imgur links",
+			rawURL:  "http://i.imgur.com/AbC123.jpg?utm_source=foo",
+			want:    "https://imgur.com/AbC123",
+			wantErr: false,
+		},
+		{
+			name:    "Should canonicalize imgur albums",
+			rawURL:  "https://m.imgur.com/a/someAlbum#0",
+			want:    "https://imgur.com/a/someAlbum",
+			wantErr: false,
+		},
+		{
+			name:    "Should canonicalize slugged imgur galleries",
+			rawURL:  "https://imgur.com/gallery/some-title-AbC123",
+			want:    "https://imgur.com/gallery/AbC123",
+			wantErr: false,
+		},
+		{
+			name:    "Should canonicalize imgur user pages",
+			rawURL:  "https://m.imgur.com/user/someone/posts?sort=newest",
+			want:    "https://imgur.com/user/someone",
+			wantErr: false,
+		},
+		{
+			name:    "Should reject relative URLs",
+			rawURL:  "/a/some-album",
+			wantErr: true,
+		},
+		{
+			name:    "Should reject schemes other than http and https",
+			rawURL:  "ftp://imgur.com/a/some-album",
+			wantErr: true,
+		},
+		{
+			name:    "Should reject empty URLs",
+			rawURL:  " ",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeURL(tt.rawURL)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NormalizeURL() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeURL() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMedia(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       media.Media
+		want    media.Media
+		wantErr Errors
+	}{
+		{
+			name: "Should return normalized media",
+			m: media.Media{
+				URL:    "https://imgur.com/a/AbC123",
+				Parent: []string{"u", " some_author "},
+			},
+			want: media.Media{
+				URL:    "https://imgur.com/a/AbC123",
+				Parent: []string{"u", "some_author"},
+			},
+		},
+		{
+			name: "Should reject path traversal in parent",
+			m: media.Media{
+				URL:    "https://imgur.com/a/some-album",
+				Parent: []string{"u", "..", "a/b"},
+			},
+			wantErr: Errors{
+				{Field: "parent[1]", Code: CodeInvalid, Message: "segment must only contain letters, digits, '.', '_' and '-' and must not start with '.' or '-'"},
+				{Field: "parent[2]", Code: CodeInvalid, Message: "segment must only contain letters, digits, '.', '_' and '-' and must not start with '.' or '-'"},
+			},
+		},
+		{
+			name: "Should report every invalid field",
+			m: media.Media{
+				URL:    "",
+				Parent: []string{""},
+			},
+			wantErr: Errors{
+				{Field: "url", Code: CodeRequired, Message: "url is required"},
+				{Field: "parent[0]", Code: CodeRequired, Message: "segment must not be empty"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Media(tt.m)
+			if tt.wantErr != nil {
+				if !reflect.DeepEqual(err, tt.wantErr) {
+					t.Errorf("Media() error = %#v, wantErr %#v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Media() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Media() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}